@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+const flowsUsage = "Usage: apix-cli flows [list [flags] | search <text> [flags] | get <id> | rm <id>... | rm --filter <expr> | clear]"
+
+// runFlows queries and deletes stored flows:
+//
+//	flows [list] [flags]          lists flows, see -h for paging, sorting and filtering
+//	flows search <text>           lists flows with text in their URL, headers or bodies
+//	flows get <id>                shows a flow with its headers and bodies
+//	flows rm <id>... | --filter   deletes flows
+//	flows clear                   deletes every flow
+func runFlows(client apix.EngineClient, args []string) {
+	cmd := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch cmd {
+	case "list", "search":
+		fs := flag.NewFlagSet("flows "+cmd, flag.ExitOnError)
+		match := fs.String("filter", "", "only list flows matching an expression")
+		sort := fs.String("sort", "", "field to sort by, prefixed with - for descending, e.g. -duration (default seq)")
+		limit := fs.Int("limit", 0, "flows per page (default 100)")
+		page := fs.String("page", "", "page token printed with the previous page")
+		var query string
+		if cmd == "search" {
+			if len(args) == 0 {
+				log.Fatal(flowsUsage)
+			}
+			query, args = args[0], args[1:]
+		}
+		fs.Parse(args)
+
+		var resp *apix.ListFlowsResponse
+		var err error
+		if cmd == "search" {
+			resp, err = client.SearchFlows(ctx, &apix.SearchFlowsRequest{
+				Query: query, Filter: *match, Sort: *sort, PageSize: int32(*limit), PageToken: *page,
+			})
+		} else {
+			resp, err = client.ListFlows(ctx, &apix.ListFlowsRequest{
+				Filter: *match, Sort: *sort, PageSize: int32(*limit), PageToken: *page,
+			})
+		}
+		if err != nil {
+			log.Fatalf("flows %s failed: %v", cmd, err)
+		}
+		for _, flow := range resp.Flows {
+			fmt.Printf("#%d %s %s\n", flow.Seq, flow.Id, formatFlow(flow))
+		}
+		fmt.Printf("%d of %d flows\n", len(resp.Flows), resp.Total)
+		if resp.NextPageToken != "" {
+			fmt.Printf("More with --page %s\n", resp.NextPageToken)
+		}
+
+	case "get":
+		if len(args) != 1 {
+			log.Fatal(flowsUsage)
+		}
+		flow, err := client.GetFlow(ctx, &apix.GetFlowRequest{Id: args[0]})
+		if err != nil {
+			log.Fatalf("flows get failed: %v", err)
+		}
+		printFlow(flow)
+
+	case "rm":
+		fs := flag.NewFlagSet("flows rm", flag.ExitOnError)
+		match := fs.String("filter", "", "delete flows matching an expression")
+		fs.Parse(args)
+		if fs.NArg() == 0 && *match == "" {
+			log.Fatal(flowsUsage)
+		}
+		resp, err := client.DeleteFlows(ctx, &apix.DeleteFlowsRequest{Ids: fs.Args(), Filter: *match})
+		if err != nil {
+			log.Fatalf("flows rm failed: %v", err)
+		}
+		fmt.Printf("Deleted %d flows\n", resp.Deleted)
+
+	case "clear":
+		resp, err := client.ClearFlows(ctx, &apix.ClearFlowsRequest{})
+		if err != nil {
+			log.Fatalf("flows clear failed: %v", err)
+		}
+		fmt.Printf("Deleted %d flows\n", resp.Deleted)
+
+	default:
+		log.Fatal(flowsUsage)
+	}
+}
+
+// printFlow shows a flow in full, the request prefixed with "> " and the
+// response with "< ", as curl -v does.
+func printFlow(flow *apix.Flow) {
+	fmt.Printf("Flow %s (#%d), %s\n", flow.Id, flow.Seq, time.UnixMilli(flow.StartedAtMs).Format(time.RFC3339Nano))
+	fmt.Println(formatFlow(flow))
+	if flow.Timing != nil {
+		fmt.Println(formatTiming(flow.Timing))
+	}
+	if flow.UpstreamTls != nil {
+		for _, line := range formatTLS(flow.UpstreamTls) {
+			fmt.Println(line)
+		}
+	}
+
+	if req := flow.Request; req != nil {
+		fmt.Println()
+		fmt.Printf("> %s %s %s\n", req.Method, req.Url, flow.ClientProtocol)
+		printHeaders("> ", req.HeaderList)
+		printBody(req.Body, req.BodySize, req.BodyTruncated)
+		printHeaders("> ", req.Trailers)
+	}
+	if resp := flow.Response; resp != nil {
+		fmt.Println()
+		fmt.Printf("< %d %s\n", resp.StatusCode, http.StatusText(int(resp.StatusCode)))
+		printHeaders("< ", resp.HeaderList)
+		printBody(resp.Body, resp.BodySize, resp.BodyTruncated)
+		printHeaders("< ", resp.Trailers)
+	}
+	for _, event := range flow.SseEvents {
+		fmt.Printf("event %s: %s\n", event.Event, event.Data)
+	}
+}
+
+func printHeaders(prefix string, headers []*apix.Header) {
+	for _, h := range headers {
+		fmt.Printf("%s%s: %s\n", prefix, h.Name, h.Value)
+	}
+}
+
+func printBody(body []byte, size int64, truncated bool) {
+	switch {
+	case size == 0:
+		return
+	case utf8.Valid(body):
+		fmt.Println()
+		fmt.Println(string(body))
+	default:
+		fmt.Printf("\n(%d bytes of binary data)\n", len(body))
+	}
+	if truncated {
+		fmt.Printf("(truncated, %d bytes in total)\n", size)
+	}
+}
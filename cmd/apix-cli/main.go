@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	apix "github.com/mnafshin/apix/pkg/api/generated"
@@ -13,7 +17,7 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: apix-cli [status|log|plugins]")
+		fmt.Println("Usage: apix-cli [status|log|flows|ws|sse|plugins|network|dns]")
 		os.Exit(1)
 	}
 
@@ -34,6 +38,11 @@ func main() {
 			log.Fatalf("GetStatus failed: %v", err)
 		}
 		fmt.Printf("Engine status: %s (version %s)\n", resp.Status, resp.Version)
+		if r := resp.Retention; r != nil {
+			fmt.Println(formatRetention(r))
+			fmt.Printf("Evicted: %d flows, %s (%d by count, %d by size, %d by age)\n",
+				r.EvictedFlows, formatBytes(r.EvictedBytes), r.EvictedByCount, r.EvictedBySize, r.EvictedByAge)
+		}
 
 	case "plugins":
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -48,21 +57,430 @@ func main() {
 		}
 
 	case "log":
+		fs := flag.NewFlagSet("log", flag.ExitOnError)
+		timing := fs.Bool("timing", false, "show a timing waterfall under each flow")
+		showTLS := fs.Bool("tls", false, "show the upstream TLS parameters and certificate chain under each flow")
+		policy := fs.String("policy", "", "what to do when falling behind: drop-oldest, drop-newest, block or disconnect (default: engine setting)")
+		buffer := fs.Int("buffer", 0, "flows the engine queues for this log before applying the policy (default: engine setting)")
+		blockTimeout := fs.Duration("block-timeout", 0, "how long the block policy may hold up capture (default: engine setting)")
+		match := fs.String("filter", "", "only show flows matching an expression, e.g. 'host ~ \"api\\\\.example\" && status >= 400'")
+		showSeq := fs.Bool("seq", false, "show the sequence number of each flow, e.g. to resume with --from-seq")
+		fromSeq := fs.Uint64("from-seq", 0, "first show the stored flows from this sequence number on")
+		last := fs.Int("last", 0, "first show the latest `n` stored flows")
+		since := fs.String("since", "", "first show the stored flows started since a time (RFC 3339) or for a duration, e.g. 10m")
+		fs.Parse(os.Args[2:])
+
+		req := &apix.CaptureRequest{
+			Policy:         *policy,
+			BufferSize:     int32(*buffer),
+			BlockTimeoutMs: blockTimeout.Milliseconds(),
+			FromSeq:        *fromSeq,
+			Last:           int32(*last),
+			Filter:         *match,
+		}
+		if *since != "" {
+			t, err := parseSince(*since)
+			if err != nil {
+				log.Fatalf("Invalid --since: %v", err)
+			}
+			req.SinceMs = t.UnixMilli()
+		}
 		ctx := context.Background()
-		stream, err := client.CaptureTraffic(ctx, &apix.CaptureRequest{})
+		stream, err := client.CaptureTraffic(ctx, req)
 		if err != nil {
 			log.Fatalf("CaptureTraffic failed: %v", err)
 		}
 		fmt.Println("Streaming captured traffic...")
+		// Streaming responses arrive once when their headers are in and again
+		// when they complete; both lines share the flow's number.
+		seen := make(map[string]int)
 		for {
-			req, err := stream.Recv()
+			flow, err := stream.Recv()
 			if err != nil {
 				log.Fatalf("stream error: %v", err)
 			}
-			fmt.Printf("[%s] %s %s\n", time.Unix(req.Timestamp, 0), req.Method, req.Url)
+			if gap := flow.Gap; gap != nil {
+				fmt.Printf("... missed %d flows (%d so far)\n", gap.Dropped, gap.TotalDropped)
+				continue
+			}
+			n, ok := seen[flow.Id]
+			if !ok {
+				n = len(seen) + 1
+				seen[flow.Id] = n
+			}
+			if *showSeq {
+				fmt.Printf("[%d] #%d %s\n", n, flow.Seq, formatFlow(flow))
+			} else {
+				fmt.Printf("[%d] %s\n", n, formatFlow(flow))
+			}
+			if *timing && flow.Timing != nil {
+				fmt.Printf("    %s\n", formatTiming(flow.Timing))
+			}
+			if *showTLS && flow.UpstreamTls != nil {
+				for _, line := range formatTLS(flow.UpstreamTls) {
+					fmt.Printf("    %s\n", line)
+				}
+			}
+		}
+
+	case "ws":
+		req := &apix.WebSocketCaptureRequest{}
+		if len(os.Args) > 2 {
+			req.FlowId = os.Args[2]
+		}
+		stream, err := client.CaptureWebSocket(context.Background(), req)
+		if err != nil {
+			log.Fatalf("CaptureWebSocket failed: %v", err)
+		}
+		fmt.Println("Streaming WebSocket frames...")
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				log.Fatalf("stream error: %v", err)
+			}
+			fmt.Println(formatFrame(frame))
+		}
+
+	case "sse":
+		req := &apix.ServerSentEventCaptureRequest{}
+		if len(os.Args) > 2 {
+			req.FlowId = os.Args[2]
+		}
+		stream, err := client.CaptureServerSentEvents(context.Background(), req)
+		if err != nil {
+			log.Fatalf("CaptureServerSentEvents failed: %v", err)
+		}
+		fmt.Println("Streaming server-sent events...")
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				log.Fatalf("stream error: %v", err)
+			}
+			fmt.Println(formatEvent(event))
+		}
+
+	case "network":
+		// network                        lists profiles and where they apply
+		// network set <profile|none> [host] applies a profile globally or to host
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		var resp *apix.NetworkProfilesResponse
+		if len(os.Args) > 2 && os.Args[2] == "set" {
+			if len(os.Args) < 4 {
+				log.Fatalf("Usage: apix-cli network set <profile|none> [host]")
+			}
+			req := &apix.SetNetworkProfileRequest{Profile: os.Args[3]}
+			if req.Profile == "none" {
+				req.Profile = ""
+			}
+			if len(os.Args) > 4 {
+				req.Host = os.Args[4]
+			}
+			resp, err = client.SetNetworkProfile(ctx, req)
+			if err != nil {
+				log.Fatalf("SetNetworkProfile failed: %v", err)
+			}
+		} else {
+			resp, err = client.GetNetworkProfiles(ctx, &apix.NetworkProfilesRequest{})
+			if err != nil {
+				log.Fatalf("GetNetworkProfiles failed: %v", err)
+			}
+		}
+		fmt.Println("Network profiles:")
+		for _, p := range resp.Profiles {
+			fmt.Printf(" - %s\n", formatNetworkProfile(p))
+		}
+		fmt.Println("Applied:")
+		if len(resp.Assignments) == 0 {
+			fmt.Println(" (none)")
+		}
+		for _, a := range resp.Assignments {
+			host := a.Host
+			if host == "" {
+				host = "*"
+			}
+			fmt.Printf(" %s -> %s\n", host, a.Profile)
+		}
+
+	case "dns":
+		// dns                       lists host overrides
+		// dns set <host> <ip|name>  overrides host, e.g. "*.example.com"
+		// dns rm <host>             removes the override
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		var resp *apix.HostOverridesResponse
+		switch {
+		case len(os.Args) > 4 && os.Args[2] == "set":
+			resp, err = client.SetHostOverride(ctx, &apix.HostOverride{Host: os.Args[3], Target: os.Args[4]})
+		case len(os.Args) > 3 && os.Args[2] == "rm":
+			resp, err = client.SetHostOverride(ctx, &apix.HostOverride{Host: os.Args[3]})
+		case len(os.Args) > 2:
+			log.Fatalf("Usage: apix-cli dns [set <host> <ip|name> | rm <host>]")
+		default:
+			resp, err = client.GetHostOverrides(ctx, &apix.HostOverridesRequest{})
+		}
+		if err != nil {
+			log.Fatalf("dns failed: %v", err)
+		}
+		fmt.Printf("Resolver: %s\n", resp.Resolver)
+		fmt.Println("Host overrides:")
+		if len(resp.Overrides) == 0 {
+			fmt.Println(" (none)")
+		}
+		for _, o := range resp.Overrides {
+			fmt.Printf(" %s -> %s\n", o.Host, o.Target)
+		}
+
+	case "flows":
+		runFlows(client, os.Args[2:])
+
+	default:
+		fmt.Println("Unknown command. Use: status, log, flows, ws, sse, plugins, network, dns")
+	}
+}
+
+// formatFlow renders a flow as a single log line, e.g.
+// "GET https://example.com/ - 200 OK".
+func formatFlow(flow *apix.Flow) string {
+	line := formatExchange(flow)
+	if ip := flow.RemoteIp; ip != "" && !strings.Contains(flow.Request.GetUrl(), ip) && (flow.Tunnel == nil || !strings.Contains(flow.Tunnel.Host, ip)) {
+		line += " at " + ip
+	}
+	if flow.UpstreamRoute != "" && flow.UpstreamRoute != "direct" {
+		line += " via " + flow.UpstreamRoute
+	}
+	if flow.ProxyUser != "" {
+		line += " by " + flow.ProxyUser
+	}
+	if flow.NetworkProfile != "" {
+		line += " on " + flow.NetworkProfile
+	}
+	if flow.RemoteUrl != "" {
+		line += " (mapped to " + flow.RemoteUrl + ")"
+	}
+	if flow.LocalFile != "" {
+		line += " (served locally from " + flow.LocalFile + ")"
+	}
+	if flow.Fault != nil {
+		line += " [fault: " + flow.Fault.Detail + "]"
+	}
+	return line
+}
+
+// formatNetworkProfile summarises a profile, e.g.
+// "3G: 1600/768 kbps, 150±50 ms".
+func formatNetworkProfile(p *apix.NetworkProfile) string {
+	var parts []string
+	if p.DownloadKbps > 0 || p.UploadKbps > 0 {
+		parts = append(parts, fmt.Sprintf("%s/%s kbps", kbps(p.DownloadKbps), kbps(p.UploadKbps)))
+	}
+	if p.LatencyMs > 0 || p.JitterMs > 0 {
+		latency := fmt.Sprintf("%d", p.LatencyMs)
+		if p.JitterMs > 0 {
+			latency += fmt.Sprintf("±%d", p.JitterMs)
 		}
+		parts = append(parts, latency+" ms")
+	}
+	if p.ResetProbability > 0 {
+		parts = append(parts, fmt.Sprintf("%g%% resets", p.ResetProbability*100))
+	}
+	if len(parts) == 0 {
+		return p.Name
+	}
+	return p.Name + ": " + strings.Join(parts, ", ")
+}
 
+func kbps(v int64) string {
+	if v <= 0 {
+		return "∞"
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+// parseSince accepts a time or a duration back from now.
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// formatRetention summarises the capture history against its limits, e.g.
+// "History: 120/10000 flows, 1.5 MiB/512.0 MiB, max age 1h0m0s".
+func formatRetention(r *apix.RetentionStats) string {
+	line := fmt.Sprintf("History: %d", r.Flows)
+	if r.MaxFlows > 0 {
+		line += fmt.Sprintf("/%d", r.MaxFlows)
+	}
+	line += " flows, " + formatBytes(r.Bytes)
+	if r.MaxBytes > 0 {
+		line += "/" + formatBytes(r.MaxBytes)
+	}
+	if r.MaxAgeSeconds > 0 {
+		line += fmt.Sprintf(", max age %v", time.Duration(r.MaxAgeSeconds)*time.Second)
+	}
+	return line
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatExchange(flow *apix.Flow) string {
+	if t := flow.Tunnel; t != nil {
+		protocol := t.Protocol
+		if protocol == "" {
+			protocol = "CONNECT"
+		}
+		return fmt.Sprintf("%s %s - tunnel (%d bytes out, %d bytes in, %dms)",
+			protocol, t.Host, t.BytesOut, t.BytesIn, t.DurationMs)
+	}
+
+	line := fmt.Sprintf("%s %s", flow.Request.GetMethod(), flow.Request.GetUrl())
+	switch {
+	case flow.Response != nil:
+		code := int(flow.Response.StatusCode)
+		line += fmt.Sprintf(" - %d %s", code, http.StatusText(code))
+		switch {
+		case code == http.StatusSwitchingProtocols:
+			line += " (flow " + flow.Id + ")"
+		case flow.Open:
+			line += " (streaming, flow " + flow.Id + ")"
+		case len(flow.SseEvents) > 0:
+			line += fmt.Sprintf(" (%d events, %dms)", len(flow.SseEvents), flow.DurationMs)
+		}
+	case flow.Error != "":
+		line += " - error: " + flow.Error
+	}
+	return line
+}
+
+// formatTLS renders TLS parameters and the certificate chain, one line for
+// the connection and one per certificate, e.g.
+// "TLS 1.3 TLS_AES_128_GCM_SHA256 (h2, SNI example.com)".
+func formatTLS(info *apix.TLSInfo) []string {
+	var details []string
+	if info.NegotiatedProtocol != "" {
+		details = append(details, info.NegotiatedProtocol)
+	}
+	if info.ServerName != "" {
+		details = append(details, "SNI "+info.ServerName)
+	}
+	if info.Resumed {
+		details = append(details, "resumed")
+	}
+	line := info.Version + " " + info.CipherSuite
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	lines := []string{line}
+	for i, cert := range info.PeerCertificates {
+		expires := time.UnixMilli(cert.NotAfterMs).UTC().Format("2006-01-02")
+		lines = append(lines, fmt.Sprintf("  [%d] %s, issued by %s, expires %s, sha256 %.16s…",
+			i, cert.Subject, cert.Issuer, expires, cert.Sha256Fingerprint))
+	}
+	return lines
+}
+
+// formatEvent renders a server-sent event as a single log line, e.g.
+// "[3a57679252bd6af5] update #7 "{\"n\":1}"".
+func formatEvent(event *apix.ServerSentEvent) string {
+	name := event.Event
+	if name == "" {
+		name = "message"
+	}
+	line := fmt.Sprintf("[%s] %s", event.FlowId, name)
+	if event.Id != "" {
+		line += " #" + event.Id
+	}
+	return line + fmt.Sprintf(" %q", event.Data)
+}
+
+// waterfallWidth is the number of characters the timing bar spans.
+const waterfallWidth = 40
+
+// formatTiming renders the upstream phases of a flow as a bar scaled to
+// their total followed by each duration, e.g.
+// "|ddccttttsswwwwwwwwwwr| dns 1.2ms connect 0.8ms tls 3.1ms ...".
+func formatTiming(t *apix.Timing) string {
+	phases := []struct {
+		name string
+		mark byte
+		us   int64
+	}{
+		{"dns", 'd', t.DnsUs},
+		{"connect", 'c', t.ConnectUs},
+		{"tls", 't', t.TlsUs},
+		{"send", 's', t.RequestWriteUs},
+		{"wait", 'w', t.TtfbUs},
+		{"receive", 'r', t.TransferUs},
+	}
+	var total int64
+	shown := 0
+	for _, p := range phases {
+		total += p.us
+		if p.us > 0 {
+			shown++
+		}
+	}
+
+	// Every phase gets a column, and the rest of the width is shared out by
+	// duration, rounding at the running total so the bar never overflows.
+	spare := int64(waterfallWidth - shown)
+	bar := make([]byte, 0, waterfallWidth)
+	var parts []string
+	var elapsed int64
+	for _, p := range phases {
+		if p.us == 0 {
+			continue
+		}
+		start := (elapsed*spare + total/2) / total
+		elapsed += p.us
+		n := 1 + int((elapsed*spare+total/2)/total-start)
+		bar = append(bar, bytes.Repeat([]byte{p.mark}, n)...)
+		parts = append(parts, fmt.Sprintf("%s %.1fms", p.name, float64(p.us)/1000))
+	}
+	line := "|" + string(bar) + "| " + strings.Join(parts, " ")
+	if t.ConnectionReused {
+		line += " (reused connection)"
+	}
+	return line
+}
+
+var opcodeNames = map[int32]string{
+	0: "CONT", 1: "TEXT", 2: "BINARY", 8: "CLOSE", 9: "PING", 10: "PONG",
+}
+
+// formatFrame renders a WebSocket frame as a single log line, e.g.
+// "[3a57679252bd6af5] -> TEXT "hello"".
+func formatFrame(frame *apix.WebSocketFrame) string {
+	arrow := "->"
+	if frame.Direction == apix.FrameDirection_SERVER_TO_CLIENT {
+		arrow = "<-"
+	}
+	name, ok := opcodeNames[frame.Opcode]
+	if !ok {
+		name = fmt.Sprintf("OP%d", frame.Opcode)
+	}
+
+	line := fmt.Sprintf("[%s] %s %s", frame.FlowId, arrow, name)
+	switch frame.Opcode {
+	case 1:
+		line += fmt.Sprintf(" %q", frame.Payload)
 	default:
-		fmt.Println("Unknown command. Use: status, log, plugins")
+		line += fmt.Sprintf(" (%d bytes)", frame.PayloadSize)
+	}
+	if frame.PayloadTruncated {
+		line += " [truncated]"
 	}
+	return line
 }
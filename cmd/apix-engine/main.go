@@ -10,6 +10,8 @@ import (
 
 	"github.com/mnafshin/apix/internal/config"
 	"github.com/mnafshin/apix/internal/engine"
+	"github.com/mnafshin/apix/internal/netsim"
+	"github.com/mnafshin/apix/internal/resolver"
 	"github.com/mnafshin/apix/internal/server"
 )
 
@@ -21,18 +23,30 @@ func main() {
 	wg := &sync.WaitGroup{}
 
 	cfg := config.LoadConfig("internal/config/config.yaml")
-	eng := engine.New()
+	eng := engine.New(cfg.Retention, cfg.Subscribers)
+	defer eng.Close()
+	network := netsim.New(cfg.Network)
+	dns := resolver.New(cfg.DNS)
+	proxy := server.NewProxy(eng, cfg, network, dns)
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		server.StartHTTPProxy(ctx, eng, cfg.HTTPPort)
+		server.StartHTTPProxy(ctx, proxy, cfg)
 	}()
 
+	if cfg.SOCKS.Enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server.StartSOCKSProxy(ctx, proxy, cfg.SOCKS)
+		}()
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		server.StartGRPCServer(ctx, eng, cfg.GRPCPort)
+		server.StartGRPCServer(ctx, eng, network, dns, cfg.GRPCPort)
 	}()
 
 	<-stop
@@ -8,8 +8,253 @@ import (
 )
 
 type Config struct {
-	HTTPPort string `yaml:"http_port"`
-	GRPCPort string `yaml:"grpc_port"`
+	HTTPPort      string              `yaml:"http_port"`
+	GRPCPort      string              `yaml:"grpc_port"`
+	MITM          MITMConfig          `yaml:"mitm"`
+	Capture       CaptureConfig       `yaml:"capture"`
+	Retention     RetentionConfig     `yaml:"retention"`
+	Subscribers   SubscribersConfig   `yaml:"subscribers"`
+	HTTP2         HTTP2Config         `yaml:"http2"`
+	Reverse       ReverseConfig       `yaml:"reverse_proxy"`
+	UpstreamProxy UpstreamProxyConfig `yaml:"upstream_proxy"`
+	SOCKS         SOCKSConfig         `yaml:"socks"`
+	Forwarding    ForwardingConfig    `yaml:"forwarding"`
+	ProxyAuth     ProxyAuthConfig     `yaml:"proxy_auth"`
+	Network       NetworkConfig       `yaml:"network"`
+	Faults        FaultsConfig        `yaml:"faults"`
+	MapLocal      MapLocalConfig      `yaml:"map_local"`
+	MapRemote     MapRemoteConfig     `yaml:"map_remote"`
+	DNS           DNSConfig           `yaml:"dns"`
+	UpstreamTLS   UpstreamTLSConfig   `yaml:"upstream_tls"`
+}
+
+// MITMConfig controls TLS interception of CONNECT tunnels. Empty CA paths
+// fall back to the per-user APiX configuration directory.
+type MITMConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	CACert        string `yaml:"ca_cert"`
+	CAKey         string `yaml:"ca_key"`
+	CertCacheSize int    `yaml:"cert_cache_size"`
+}
+
+// CaptureConfig limits how much of each request and response body is
+// recorded. Bodies larger than MemoryBodyBytes are written to SpillDir
+// (a temporary directory when empty); bytes past MaxBodyBytes are streamed
+// but not recorded.
+type CaptureConfig struct {
+	MaxBodyBytes    int64  `yaml:"max_body_bytes"`
+	MemoryBodyBytes int64  `yaml:"memory_body_bytes"`
+	SpillDir        string `yaml:"spill_dir"`
+}
+
+// RetentionConfig bounds the capture history: once it holds more than
+// MaxFlows flows, more than MaxBytes of flows, bodies, frames and events,
+// or flows older than MaxAgeSeconds, the oldest flows are evicted. Zero
+// disables a limit.
+type RetentionConfig struct {
+	MaxFlows      int   `yaml:"max_flows"`
+	MaxBytes      int64 `yaml:"max_bytes"`
+	MaxAgeSeconds int64 `yaml:"max_age_seconds"`
+}
+
+// SubscribersConfig decides what happens when a capture subscriber falls
+// behind by more than BufferSize flows. Policy is "drop-oldest" or
+// "drop-newest" to discard flows, "block" to hold up capture for up to
+// BlockTimeoutMs before dropping the new flow, or "disconnect" to end the
+// subscription. Subscribers may choose their own settings.
+type SubscribersConfig struct {
+	Policy         string `yaml:"policy"`
+	BufferSize     int    `yaml:"buffer_size"`
+	BlockTimeoutMs int64  `yaml:"block_timeout_ms"`
+}
+
+// HTTP2Config controls HTTP/2 on intercepted client connections and on
+// upstream connections. H2CHosts lists upstream hosts (glob patterns or
+// host:port) that are reached with cleartext HTTP/2 with prior knowledge.
+type HTTP2Config struct {
+	Enabled  bool     `yaml:"enabled"`
+	H2CHosts []string `yaml:"h2c_hosts"`
+}
+
+// ReverseConfig lists listeners that put APiX in front of other services.
+// Requests arriving on them are routed instead of being treated as proxy
+// requests.
+type ReverseConfig struct {
+	Listeners []ReverseListener `yaml:"listeners"`
+}
+
+// ReverseListener serves Routes on Port. The most specific matching route
+// wins: one matching the Host header beats one that does not name a host,
+// then the longest path prefix.
+type ReverseListener struct {
+	Port   string         `yaml:"port"`
+	Routes []ReverseRoute `yaml:"routes"`
+}
+
+// ReverseRoute forwards requests whose Host matches Host (a glob pattern,
+// any host when empty) and whose path starts with PathPrefix to Upstream, a
+// base URL. The prefix is replaced by the base URL's path, and Host and
+// Location headers are rewritten between the two origins.
+type ReverseRoute struct {
+	Host       string `yaml:"host"`
+	PathPrefix string `yaml:"path_prefix"`
+	Upstream   string `yaml:"upstream"`
+}
+
+// UpstreamProxyConfig chains upstream connections through other proxies.
+// Rules are tried in order and the first one with a matching host decides;
+// destinations that match no rule are dialed directly.
+type UpstreamProxyConfig struct {
+	Rules []UpstreamProxyRule `yaml:"rules"`
+}
+
+// UpstreamProxyRule sends connections to Hosts (glob patterns or host:port)
+// through Proxy, an http://, https:// or socks5:// URL with optional
+// user:password credentials, or "direct".
+type UpstreamProxyRule struct {
+	Hosts []string `yaml:"hosts"`
+	Proxy string   `yaml:"proxy"`
+}
+
+// SOCKSConfig controls the SOCKS5/SOCKS4a listener. When Users is not
+// empty, clients must authenticate with one of them, which rules out
+// SOCKS4a as it has no passwords. Users are given as for ProxyAuthConfig.
+type SOCKSConfig struct {
+	Enabled bool            `yaml:"enabled"`
+	Port    string          `yaml:"port"`
+	Users   []ProxyAuthUser `yaml:"users"`
+}
+
+// ForwardingConfig selects the headers that announce APiX to the peers of
+// a proxied exchange: Via in both directions, and X-Forwarded-For and
+// Forwarded (RFC 7239) on requests.
+type ForwardingConfig struct {
+	Via           bool `yaml:"via"`
+	XForwardedFor bool `yaml:"x_forwarded_for"`
+	Forwarded     bool `yaml:"forwarded"`
+}
+
+// ProxyAuthConfig restricts who may use the HTTP proxy listener. Clients
+// must connect from one of AllowedClients (IPs or CIDR ranges) when it is
+// not empty, and authenticate as one of Users when that is not empty.
+type ProxyAuthConfig struct {
+	Realm          string          `yaml:"realm"`
+	Users          []ProxyAuthUser `yaml:"users"`
+	AllowedClients []string        `yaml:"allowed_clients"`
+}
+
+// ProxyAuthUser is a proxy user with a bcrypt password hash, e.g. from
+// "htpasswd -nbB user password".
+type ProxyAuthUser struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"`
+}
+
+// NetworkConfig simulates network conditions on upstream connections.
+// Profile applies to every host not matched by Hosts; both may name the
+// built-in profiles ("3G", "edge", "satellite", "flaky") or ones defined in
+// Profiles.
+type NetworkConfig struct {
+	Profile  string               `yaml:"profile"`
+	Profiles []NetworkProfile     `yaml:"profiles"`
+	Hosts    []NetworkProfileRule `yaml:"hosts"`
+}
+
+type NetworkProfile struct {
+	Name             string  `yaml:"name"`
+	DownloadKbps     int64   `yaml:"download_kbps"`
+	UploadKbps       int64   `yaml:"upload_kbps"`
+	LatencyMs        int64   `yaml:"latency_ms"`
+	JitterMs         int64   `yaml:"jitter_ms"`
+	ResetProbability float64 `yaml:"reset_probability"`
+}
+
+// NetworkProfileRule applies Profile to Hosts (glob patterns or host:port).
+type NetworkProfileRule struct {
+	Hosts   []string `yaml:"hosts"`
+	Profile string   `yaml:"profile"`
+}
+
+// FaultsConfig injects failures into a share of the proxied requests.
+type FaultsConfig struct {
+	Rules []FaultRule `yaml:"rules"`
+}
+
+// FaultRule makes Probability of the requests matching URL (a pattern where
+// * matches anything) and Methods fail with Fault: status, reset, hang,
+// truncate or malformed. Without a Probability every matching request
+// fails; 0 turns the rule off.
+type FaultRule struct {
+	URL         string   `yaml:"url"`
+	Methods     []string `yaml:"methods"`
+	Fault       string   `yaml:"fault"`
+	Probability *float64 `yaml:"probability"`
+	Status      int      `yaml:"status"`
+	Body        string   `yaml:"body"`
+	DurationMs  int64    `yaml:"duration_ms"`
+	TruncateAt  int64    `yaml:"truncate_at"`
+}
+
+// MapLocalConfig serves matching requests from local files instead of
+// forwarding them.
+type MapLocalConfig struct {
+	Rules []MapLocalRule `yaml:"rules"`
+}
+
+// MapLocalRule maps URL (a pattern where * matches anything) to Path, a
+// file or a directory. Template files are run through text/template.
+type MapLocalRule struct {
+	URL      string `yaml:"url"`
+	Path     string `yaml:"path"`
+	Template bool   `yaml:"template"`
+}
+
+// MapRemoteConfig sends matching requests to a different upstream.
+type MapRemoteConfig struct {
+	Rules []MapRemoteRule `yaml:"rules"`
+}
+
+// MapRemoteRule rewrites request URLs matching the regular expression From
+// to To, which may refer to capture groups as $1 or ${name}.
+type MapRemoteRule struct {
+	From         string `yaml:"from"`
+	To           string `yaml:"to"`
+	PreserveHost bool   `yaml:"preserve_host"`
+}
+
+// DNSConfig controls how upstream host names are resolved. Without servers
+// or a DoH endpoint the system resolver is used.
+type DNSConfig struct {
+	Overrides []HostOverride `yaml:"overrides"`
+	Servers   []string       `yaml:"servers"` // host or host:port
+	DoH       string         `yaml:"doh"`     // DNS-over-HTTPS endpoint URL
+}
+
+// HostOverride sends Host, a name or a pattern such as "*.example.com", to
+// Target, an IP address or another host name.
+type HostOverride struct {
+	Host   string `yaml:"host"`
+	Target string `yaml:"target"`
+}
+
+// UpstreamTLSConfig adjusts TLS to upstream hosts. The first rule matching
+// a host applies.
+type UpstreamTLSConfig struct {
+	Rules []UpstreamTLSRule `yaml:"rules"`
+}
+
+// UpstreamTLSRule applies to Hosts (glob patterns or host:port). ClientCert
+// and ClientKey are PEM files presented for mutual TLS; CABundle is a PEM
+// file of roots trusted instead of the system ones; MinVersion is "1.0" to
+// "1.3"; ServerName replaces the host name sent as SNI and verified.
+type UpstreamTLSRule struct {
+	Hosts              []string `yaml:"hosts"`
+	ClientCert         string   `yaml:"client_cert"`
+	ClientKey          string   `yaml:"client_key"`
+	CABundle           string   `yaml:"ca_bundle"`
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"`
+	MinVersion         string   `yaml:"min_version"`
+	ServerName         string   `yaml:"server_name"`
 }
 
 // LoadConfig reads configuration from a YAML file.
@@ -18,6 +263,32 @@ func LoadConfig(path string) *Config {
 	cfg := &Config{
 		HTTPPort: "8080",
 		GRPCPort: "9090",
+		MITM: MITMConfig{
+			Enabled:       true,
+			CertCacheSize: 1000,
+		},
+		Capture: CaptureConfig{
+			MaxBodyBytes:    10 << 20,
+			MemoryBodyBytes: 1 << 20,
+		},
+		Retention: RetentionConfig{
+			MaxFlows: 10000,
+			MaxBytes: 512 << 20,
+		},
+		Subscribers: SubscribersConfig{
+			Policy:         "drop-oldest",
+			BufferSize:     1024,
+			BlockTimeoutMs: 1000,
+		},
+		HTTP2: HTTP2Config{
+			Enabled: true,
+		},
+		SOCKS: SOCKSConfig{
+			Port: "1080",
+		},
+		ProxyAuth: ProxyAuthConfig{
+			Realm: "APiX",
+		},
 	}
 
 	file, err := os.ReadFile(path)
@@ -32,4 +303,4 @@ func LoadConfig(path string) *Config {
 	}
 
 	return cfg
-}
\ No newline at end of file
+}
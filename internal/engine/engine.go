@@ -1,49 +1,233 @@
 package engine
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/filter"
 	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"google.golang.org/protobuf/proto"
 )
 
+// Engine stores captured flows and fans them out to subscribers. Flows are
+// copied on the way in, so callers may keep updating their own message
+// while a streaming response is in progress. The history is bounded by the
+// retention limits, evicting the oldest flows first.
 type Engine struct {
-	mu          sync.Mutex
-	requests    []*apix.HttpRequest
-	subscribers []chan *apix.HttpRequest
+	mu               sync.Mutex
+	ring             flowRing
+	byID             map[string]*entry
+	bytes            int64
+	limits           config.RetentionConfig
+	maxAge           time.Duration
+	stats            *apix.RetentionStats
+	seq              uint64
+	gone             map[string]bool // open flows deleted or evicted before they complete
+	stop             chan struct{}   // closed by Close
+	delivery         config.SubscribersConfig
+	subscribers      []*Subscription
+	frames           map[string][]*apix.WebSocketFrame
+	frameSubscribers []chan *apix.WebSocketFrame
+	events           map[string][]*apix.ServerSentEvent
+	eventSubscribers []chan *apix.ServerSentEvent
 }
 
-func New() *Engine {
-	return &Engine{}
+func New(retention config.RetentionConfig, delivery config.SubscribersConfig) *Engine {
+	e := &Engine{
+		byID:     make(map[string]*entry),
+		gone:     make(map[string]bool),
+		stop:     make(chan struct{}),
+		limits:   retention,
+		maxAge:   time.Duration(retention.MaxAgeSeconds) * time.Second,
+		stats:    &apix.RetentionStats{},
+		delivery: delivery,
+		frames:   make(map[string][]*apix.WebSocketFrame),
+		events:   make(map[string][]*apix.ServerSentEvent),
+	}
+	if e.maxAge > 0 {
+		go e.expire()
+	}
+	return e
+}
+
+// Close stops evicting flows as they age out.
+func (e *Engine) Close() {
+	close(e.stop)
 }
 
-func (e *Engine) AddRequest(req *apix.HttpRequest) {
+// AddFlow stores a captured flow, assigning it an ID if it has none and the
+// next sequence number, and publishes it to all subscribers.
+func (e *Engine) AddFlow(flow *apix.Flow) {
+	if flow.Id == "" {
+		flow.Id = newFlowID()
+	}
+	stored := proto.Clone(flow).(*apix.Flow)
+
 	e.mu.Lock()
-	defer e.mu.Unlock()
-	e.requests = append(e.requests, req)
+	e.seq++
+	stored.Seq = e.seq
+	files := e.store(stored)
+	e.publish(stored)
+	e.mu.Unlock()
+	removeFiles(files)
+}
+
+// UpdateFlow replaces a previously added flow, e.g. once an open streaming
+// response completes, and publishes the new version carrying every
+// server-sent event recorded for it. Flows deleted or evicted meanwhile are
+// published but not stored again.
+func (e *Engine) UpdateFlow(flow *apix.Flow) {
+	updated := proto.Clone(flow).(*apix.Flow)
+
+	size := flowSize(updated)
+
+	e.mu.Lock()
+	e.seq++
+	updated.Seq = e.seq
+	updated.SseEvents = slices.Clone(e.events[updated.Id])
+	var files []string
+	ent, ok := e.byID[updated.Id]
+	switch {
+	case ok:
+		ent.flow = updated
+		e.bytes += size + ent.extra - ent.size
+		ent.size = size + ent.extra
+		files = e.evict(time.Now())
+	case e.gone[updated.Id]:
+		if !updated.Open {
+			delete(e.gone, updated.Id)
+		}
+		files = bodyFiles(updated)
+	default:
+		files = e.store(updated)
+	}
+	e.publish(updated)
+	e.mu.Unlock()
+	removeFiles(files)
+}
+
+// publish must be called with e.mu held.
+func (e *Engine) publish(flow *apix.Flow) {
 	for _, sub := range e.subscribers {
+		sub.offer(flow)
+	}
+}
+
+// Subscribe registers a subscriber for the captured flows that match, or
+// all of them if match is nil, starting with the stored flows selected by
+// from. Zero fields of cfg take the engine's defaults.
+func (e *Engine) Subscribe(cfg config.SubscribersConfig, from Cursor, match *filter.Filter) (*Subscription, error) {
+	sub, err := newSubscription(cfg, e.delivery)
+	if err != nil {
+		return nil, err
+	}
+	sub.filter = match
+	e.mu.Lock()
+	sub.backlog = e.replay(from, match)
+	e.subscribers = append(e.subscribers, sub)
+	e.mu.Unlock()
+	return sub, nil
+}
+
+func (e *Engine) Unsubscribe(sub *Subscription) {
+	// Closing first releases a publisher blocked on the subscription, which
+	// holds e.mu.
+	sub.close()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, s := range e.subscribers {
+		if s == sub {
+			e.subscribers = append(e.subscribers[:i], e.subscribers[i+1:]...)
+			break
+		}
+	}
+}
+
+// AddWebSocketFrame stores a frame relayed on an upgraded flow and
+// publishes it to all frame subscribers.
+func (e *Engine) AddWebSocketFrame(frame *apix.WebSocketFrame) {
+	e.mu.Lock()
+	var files []string
+	if ent, ok := e.byID[frame.FlowId]; ok {
+		e.frames[frame.FlowId] = append(e.frames[frame.FlowId], frame)
+		files = e.grow(ent, int64(proto.Size(frame)))
+	}
+	for _, sub := range e.frameSubscribers {
 		select {
-		case sub <- req:
+		case sub <- frame:
 		default:
 		}
 	}
+	e.mu.Unlock()
+	removeFiles(files)
 }
 
-func (e *Engine) Subscribe() chan *apix.HttpRequest {
-	ch := make(chan *apix.HttpRequest, 10)
+func (e *Engine) SubscribeWebSocket() chan *apix.WebSocketFrame {
+	ch := make(chan *apix.WebSocketFrame, 100)
 	e.mu.Lock()
-	e.subscribers = append(e.subscribers, ch)
+	e.frameSubscribers = append(e.frameSubscribers, ch)
 	e.mu.Unlock()
 	return ch
 }
 
-func (e *Engine) Unsubscribe(ch chan *apix.HttpRequest) {
+func (e *Engine) UnsubscribeWebSocket(ch chan *apix.WebSocketFrame) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	for i, sub := range e.subscribers {
+	for i, sub := range e.frameSubscribers {
 		if sub == ch {
-			e.subscribers = append(e.subscribers[:i], e.subscribers[i+1:]...)
+			e.frameSubscribers = append(e.frameSubscribers[:i], e.frameSubscribers[i+1:]...)
+			break
+		}
+	}
+	close(ch)
+}
+
+// AddServerSentEvent records an event of an open flow and publishes it to
+// all event subscribers. Published flows are never modified, so the events
+// are attached to the flow when it is updated on completion.
+func (e *Engine) AddServerSentEvent(event *apix.ServerSentEvent) {
+	e.mu.Lock()
+	var files []string
+	if ent, ok := e.byID[event.FlowId]; ok {
+		e.events[event.FlowId] = append(e.events[event.FlowId], event)
+		files = e.grow(ent, int64(proto.Size(event)))
+	}
+	for _, sub := range e.eventSubscribers {
+		select {
+		case sub <- event:
+		default:
+		}
+	}
+	e.mu.Unlock()
+	removeFiles(files)
+}
+
+func (e *Engine) SubscribeServerSentEvents() chan *apix.ServerSentEvent {
+	ch := make(chan *apix.ServerSentEvent, 100)
+	e.mu.Lock()
+	e.eventSubscribers = append(e.eventSubscribers, ch)
+	e.mu.Unlock()
+	return ch
+}
+
+func (e *Engine) UnsubscribeServerSentEvents(ch chan *apix.ServerSentEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, sub := range e.eventSubscribers {
+		if sub == ch {
+			e.eventSubscribers = append(e.eventSubscribers[:i], e.eventSubscribers[i+1:]...)
 			break
 		}
 	}
 	close(ch)
-}
\ No newline at end of file
+}
+
+func newFlowID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
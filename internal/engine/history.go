@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"github.com/mnafshin/apix/internal/filter"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// Flows returns the stored flows that match, or all of them if match is
+// nil, oldest first. The flows are shared and must not be modified.
+func (e *Engine) Flows(match *filter.Filter) []*apix.Flow {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var flows []*apix.Flow
+	for i := range e.ring.n {
+		if flow := e.ring.at(i).flow; match.Match(flow) {
+			flows = append(flows, flow)
+		}
+	}
+	return flows
+}
+
+// Flow returns a stored flow by ID. It is shared and must not be modified.
+func (e *Engine) Flow(id string) (*apix.Flow, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ent, ok := e.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return ent.flow, true
+}
+
+// DeleteFlows removes the stored flows with the given IDs and, if match is
+// not nil, those that match, along with their frames, events and spilled
+// bodies. It returns how many flows were removed.
+func (e *Engine) DeleteFlows(ids []string, match *filter.Filter) int {
+	listed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+	}
+	e.mu.Lock()
+	n, files := e.remove(func(flow *apix.Flow) bool {
+		return listed[flow.Id] || match != nil && match.Match(flow)
+	})
+	e.mu.Unlock()
+	removeFiles(files)
+	return n
+}
+
+// ClearFlows removes every stored flow and returns how many there were.
+func (e *Engine) ClearFlows() int {
+	e.mu.Lock()
+	n, files := e.remove(func(*apix.Flow) bool { return true })
+	e.mu.Unlock()
+	removeFiles(files)
+	return n
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"google.golang.org/protobuf/proto"
+)
+
+// entry is a stored flow with what it costs to keep it.
+type entry struct {
+	flow  *apix.Flow
+	size  int64 // the flow, its spilled bodies and its frames and events
+	extra int64 // frames and events
+	added time.Time
+}
+
+// flowRing holds the capture history oldest first. It grows as needed and
+// is kept in bounds by eviction from the front.
+type flowRing struct {
+	buf  []*entry
+	head int
+	n    int
+}
+
+func (r *flowRing) push(e *entry) {
+	if r.n == len(r.buf) {
+		buf := make([]*entry, max(16, 2*len(r.buf)))
+		for i := range r.n {
+			buf[i] = r.at(i)
+		}
+		r.buf, r.head = buf, 0
+	}
+	r.buf[(r.head+r.n)%len(r.buf)] = e
+	r.n++
+}
+
+// at returns the i-th oldest entry.
+func (r *flowRing) at(i int) *entry {
+	return r.buf[(r.head+i)%len(r.buf)]
+}
+
+func (r *flowRing) pop() *entry {
+	e := r.buf[r.head]
+	r.buf[r.head] = nil
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return e
+}
+
+// store appends a new flow to the history. It must be called with e.mu
+// held; the returned body files of evicted flows are to be removed once it
+// is released.
+func (e *Engine) store(flow *apix.Flow) []string {
+	ent := &entry{flow: flow, size: flowSize(flow), added: time.Now()}
+	e.ring.push(ent)
+	e.byID[flow.Id] = ent
+	e.bytes += ent.size
+	return e.evict(ent.added)
+}
+
+// grow accounts for n more bytes kept for the flow of ent.
+func (e *Engine) grow(ent *entry, n int64) []string {
+	ent.extra += n
+	ent.size += n
+	e.bytes += n
+	return e.evict(time.Now())
+}
+
+// evict drops the oldest flows, with their frames and events, until the
+// history is within its limits, and returns their spilled body files. It
+// must be called with e.mu held.
+func (e *Engine) evict(now time.Time) []string {
+	var files []string
+	for e.ring.n > 0 {
+		oldest := e.ring.at(0)
+		var reason *int64
+		switch {
+		case e.limits.MaxFlows > 0 && e.ring.n > e.limits.MaxFlows:
+			reason = &e.stats.EvictedByCount
+		case e.limits.MaxBytes > 0 && e.bytes > e.limits.MaxBytes:
+			reason = &e.stats.EvictedBySize
+		case e.maxAge > 0 && now.Sub(oldest.added) > e.maxAge:
+			reason = &e.stats.EvictedByAge
+		default:
+			return files
+		}
+		e.ring.pop()
+		*reason++
+		e.stats.EvictedFlows++
+		e.stats.EvictedBytes += oldest.size
+		files = append(files, e.forget(oldest)...)
+	}
+	return files
+}
+
+// remove deletes the flows for which drop returns true, and returns how
+// many there were and their spilled body files. It must be called with
+// e.mu held.
+func (e *Engine) remove(drop func(*apix.Flow) bool) (int, []string) {
+	var kept flowRing
+	var files []string
+	n := 0
+	for i := range e.ring.n {
+		ent := e.ring.at(i)
+		if !drop(ent.flow) {
+			kept.push(ent)
+			continue
+		}
+		n++
+		files = append(files, e.forget(ent)...)
+	}
+	e.ring = kept
+	return n, files
+}
+
+// forget drops what is kept for the flow of an entry that has left the
+// history and returns its spilled body files. Open flows are remembered so
+// their completion does not bring them back.
+func (e *Engine) forget(ent *entry) []string {
+	e.bytes -= ent.size
+	id := ent.flow.Id
+	if ent.flow.Open {
+		e.gone[id] = true
+	}
+	delete(e.byID, id)
+	delete(e.frames, id)
+	delete(e.events, id)
+	return bodyFiles(ent.flow)
+}
+
+// expire evicts flows as they age out, so a quiet engine does not hold on
+// to them until the next capture.
+func (e *Engine) expire() {
+	ticker := time.NewTicker(max(e.maxAge/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			e.mu.Lock()
+			files := e.evict(now)
+			e.mu.Unlock()
+			removeFiles(files)
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Retention reports the size of the capture history, its limits and the
+// evictions so far.
+func (e *Engine) Retention() *apix.RetentionStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := proto.Clone(e.stats).(*apix.RetentionStats)
+	stats.Flows = int64(e.ring.n)
+	stats.Bytes = e.bytes
+	stats.MaxFlows = int64(e.limits.MaxFlows)
+	stats.MaxBytes = e.limits.MaxBytes
+	stats.MaxAgeSeconds = e.limits.MaxAgeSeconds
+	return stats
+}
+
+// flowSize estimates the memory and disk a flow takes up. Server-sent
+// events are accounted for as they arrive, so it is taken before they are
+// attached to a completed flow.
+func flowSize(flow *apix.Flow) int64 {
+	size := int64(proto.Size(flow))
+	if req := flow.GetRequest(); req.GetBodyFile() != "" {
+		size += req.GetBodySize()
+	}
+	if resp := flow.GetResponse(); resp.GetBodyFile() != "" {
+		size += resp.GetBodySize()
+	}
+	return size
+}
+
+func bodyFiles(flow *apix.Flow) []string {
+	var files []string
+	if f := flow.GetRequest().GetBodyFile(); f != "" {
+		files = append(files, f)
+	}
+	if f := flow.GetResponse().GetBodyFile(); f != "" {
+		files = append(files, f)
+	}
+	return files
+}
+
+func removeFiles(files []string) {
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to remove evicted body file: %v", err)
+		}
+	}
+}
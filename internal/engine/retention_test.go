@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mnafshin/apix/internal/config"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+func historyIDs(e *Engine) []string {
+	var ids []string
+	for _, flow := range e.Flows(nil) {
+		ids = append(ids, flow.Id)
+	}
+	return ids
+}
+
+func TestCompletedFlowsStayGone(t *testing.T) {
+	tests := []struct {
+		name string
+		drop func(e *Engine)
+	}{
+		{"evicted", func(e *Engine) {
+			for i := 1; i <= 3; i++ {
+				e.AddFlow(&apix.Flow{Id: fmt.Sprint(i)})
+			}
+		}},
+		{"deleted", func(e *Engine) {
+			e.DeleteFlows([]string{"open"}, nil)
+			for i := 1; i <= 3; i++ {
+				e.AddFlow(&apix.Flow{Id: fmt.Sprint(i)})
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(config.RetentionConfig{MaxFlows: 3}, config.SubscribersConfig{Policy: DropNewest, BufferSize: 10})
+			defer e.Close()
+			e.AddFlow(&apix.Flow{Id: "open", Open: true})
+			tt.drop(e)
+
+			e.UpdateFlow(&apix.Flow{Id: "open"})
+			if got, want := historyIDs(e), []string{"1", "2", "3"}; !slices.Equal(got, want) {
+				t.Errorf("history is %v after completion, want %v", got, want)
+			}
+			if _, ok := e.Flow("open"); ok {
+				t.Error("completed flow is back in the history")
+			}
+			if len(e.gone) != 0 {
+				t.Errorf("still remembering %d gone flows", len(e.gone))
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/filter"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// Delivery policies for subscribers that fall behind.
+const (
+	DropOldest = "drop-oldest" // discard the oldest waiting flow
+	DropNewest = "drop-newest" // discard the new flow
+	Block      = "block"       // hold up capture until there is room or the timeout expires, then drop the new flow
+	Disconnect = "disconnect"  // end the subscription
+)
+
+var (
+	// ErrSlowSubscriber ends a subscription with the disconnect policy once
+	// its buffer overflows.
+	ErrSlowSubscriber = errors.New("subscriber fell behind")
+
+	errUnsubscribed = errors.New("unsubscribed")
+)
+
+// Cursor selects the stored flows a subscription starts with, using the
+// first of its fields that is set. The zero Cursor selects none.
+type Cursor struct {
+	FromSeq uint64    // flows from this sequence number on
+	Last    int       // the latest Last flows
+	Since   time.Time // flows started at or after Since
+}
+
+// replay returns the stored flows selected by c that match in stream
+// order. If flows from c.FromSeq on have already been evicted, a gap
+// marker for them comes first; not all of them need have matched. It must
+// be called with e.mu held.
+func (e *Engine) replay(c Cursor, match *filter.Filter) []*apix.Flow {
+	if c.FromSeq == 0 && c.Last <= 0 && c.Since.IsZero() {
+		return nil
+	}
+	var flows []*apix.Flow
+	oldest := e.seq + 1
+	for i := range e.ring.n {
+		flow := e.ring.at(i).flow
+		oldest = min(oldest, flow.Seq)
+		switch {
+		case c.FromSeq > 0:
+			if flow.Seq < c.FromSeq {
+				continue
+			}
+		case c.Last > 0:
+		case flow.StartedAtMs < c.Since.UnixMilli():
+			continue
+		}
+		if match.Match(flow) {
+			flows = append(flows, flow)
+		}
+	}
+	// Flows updated on completion keep their place in the history but move
+	// on in the stream.
+	slices.SortFunc(flows, func(a, b *apix.Flow) int { return cmp.Compare(a.Seq, b.Seq) })
+	if c.FromSeq == 0 && c.Last > 0 && len(flows) > c.Last {
+		flows = flows[len(flows)-c.Last:]
+	}
+	if c.FromSeq > 0 && c.FromSeq < oldest {
+		gap := &apix.Flow{Gap: &apix.Gap{Dropped: int64(oldest - c.FromSeq)}}
+		flows = append([]*apix.Flow{gap}, flows...)
+	}
+	return flows
+}
+
+// Subscription queues captured flows for one subscriber, skipping those
+// that do not match its filter. Flows it has no room for are dropped
+// according to its policy and replaced by a gap marker, a flow with only
+// Gap set, so the subscriber knows what it missed. Stored flows it was
+// started with are sent first and never dropped.
+type Subscription struct {
+	policy  string
+	size    int
+	timeout time.Duration
+	filter  *filter.Filter
+
+	mu       sync.Mutex
+	backlog  []*apix.Flow
+	queue    []*apix.Flow
+	queued   int   // flows in queue, not counting gap markers
+	reported int64 // flows missed per the gap markers taken so far
+	err      error
+	ready    chan struct{} // signalled when the queue or err changes
+	space    chan struct{} // signalled when a flow is taken
+}
+
+// newSubscription applies cfg over the defaults.
+func newSubscription(cfg, defaults config.SubscribersConfig) (*Subscription, error) {
+	if cfg.Policy == "" {
+		cfg.Policy = defaults.Policy
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = defaults.BufferSize
+	}
+	if cfg.BlockTimeoutMs == 0 {
+		cfg.BlockTimeoutMs = defaults.BlockTimeoutMs
+	}
+	switch cfg.Policy {
+	case DropOldest, DropNewest, Block, Disconnect:
+	default:
+		return nil, fmt.Errorf("unknown delivery policy %q", cfg.Policy)
+	}
+	if cfg.BufferSize < 1 {
+		return nil, fmt.Errorf("invalid buffer size %d", cfg.BufferSize)
+	}
+	if cfg.BlockTimeoutMs < 0 {
+		return nil, fmt.Errorf("invalid block timeout %dms", cfg.BlockTimeoutMs)
+	}
+	return &Subscription{
+		policy:  cfg.Policy,
+		size:    cfg.BufferSize,
+		timeout: time.Duration(cfg.BlockTimeoutMs) * time.Millisecond,
+		ready:   make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+	}, nil
+}
+
+// offer queues a flow, making room for it according to the policy.
+func (s *Subscription) offer(flow *apix.Flow) {
+	if !s.filter.Match(flow) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if s.queued == s.size && s.policy == Block {
+		s.wait()
+	}
+
+	switch {
+	case s.err != nil:
+		return
+	case s.queued < s.size:
+		s.queue = append(s.queue, flow)
+		s.queued++
+	case s.policy == DropOldest:
+		// Gaps only ever sit at the front of such a queue.
+		if gap := s.queue[0].Gap; gap != nil {
+			gap.Dropped++
+			s.queue[1] = s.queue[0]
+			s.queue = s.queue[1:]
+		} else {
+			s.queue[0] = &apix.Flow{Gap: &apix.Gap{Dropped: 1}}
+		}
+		s.queue = append(s.queue, flow)
+	case s.policy == Disconnect:
+		// What is queued is still delivered before the error.
+		s.err = ErrSlowSubscriber
+	default:
+		if gap := s.queue[len(s.queue)-1].Gap; gap != nil {
+			gap.Dropped++
+		} else {
+			s.queue = append(s.queue, &apix.Flow{Gap: &apix.Gap{Dropped: 1}})
+		}
+	}
+	signal(s.ready)
+}
+
+// wait blocks until the subscriber takes a flow or the block timeout
+// expires. It is called with s.mu held.
+func (s *Subscription) wait() {
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	for s.queued == s.size && s.err == nil {
+		s.mu.Unlock()
+		select {
+		case <-s.space:
+		case <-timer.C:
+			s.mu.Lock()
+			return
+		}
+		s.mu.Lock()
+	}
+}
+
+// Next returns the next flow or gap marker, waiting for one if needed. It
+// fails once the subscription has ended and its queue is drained.
+func (s *Subscription) Next(ctx context.Context) (*apix.Flow, error) {
+	for {
+		s.mu.Lock()
+		var flow *apix.Flow
+		switch {
+		case len(s.backlog) > 0:
+			flow = s.backlog[0]
+			s.backlog[0] = nil
+			s.backlog = s.backlog[1:]
+		case len(s.queue) > 0:
+			flow = s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			if flow.Gap == nil {
+				s.queued--
+				signal(s.space)
+			}
+		}
+		if flow != nil {
+			if flow.Gap != nil {
+				s.reported += flow.Gap.Dropped
+				flow.Gap.TotalDropped = s.reported
+			}
+			s.mu.Unlock()
+			return flow, nil
+		}
+		err := s.err
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *Subscription) close() {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = errUnsubscribed
+	}
+	s.backlog, s.queue = nil, nil
+	s.mu.Unlock()
+	signal(s.ready)
+	signal(s.space)
+}
+
+// signal wakes up a waiter on ch without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
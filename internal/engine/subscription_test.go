@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/filter"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// drain takes what a subscription has ready without waiting, written as
+// flow IDs, "gap D/T" for gap markers and the error that ended it.
+func drain(t *testing.T, sub *Subscription) []string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var got []string
+	for {
+		flow, err := sub.Next(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return got
+		case err != nil:
+			return append(got, err.Error())
+		case flow.Gap != nil:
+			got = append(got, fmt.Sprintf("gap %d/%d", flow.Gap.Dropped, flow.Gap.TotalDropped))
+		default:
+			got = append(got, flow.Id)
+		}
+	}
+}
+
+func offerFlows(sub *Subscription, ids ...string) {
+	for _, id := range ids {
+		sub.offer(&apix.Flow{Id: id})
+	}
+}
+
+func TestDeliveryPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		first  []string // after offering 1 to 5
+		second []string // after offering 6 to 8 next
+	}{
+		{DropOldest, []string{"gap 3/3", "4", "5"}, []string{"gap 1/4", "7", "8"}},
+		{DropNewest, []string{"1", "2", "gap 3/3"}, []string{"6", "7", "gap 1/4"}},
+		{Block, []string{"1", "2", "gap 3/3"}, []string{"6", "7", "gap 1/4"}},
+		{Disconnect, []string{"1", "2", ErrSlowSubscriber.Error()}, []string{ErrSlowSubscriber.Error()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			sub, err := newSubscription(config.SubscribersConfig{Policy: tt.policy, BufferSize: 2, BlockTimeoutMs: 1}, config.SubscribersConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			offerFlows(sub, "1", "2", "3", "4", "5")
+			if got := drain(t, sub); !slices.Equal(got, tt.first) {
+				t.Errorf("got %v, want %v", got, tt.first)
+			}
+			offerFlows(sub, "6", "7", "8")
+			if got := drain(t, sub); !slices.Equal(got, tt.second) {
+				t.Errorf("then got %v, want %v", got, tt.second)
+			}
+		})
+	}
+}
+
+func TestSubscriptionDefaults(t *testing.T) {
+	defaults := config.SubscribersConfig{Policy: DropNewest, BufferSize: 4, BlockTimeoutMs: 10}
+	tests := []struct {
+		cfg     config.SubscribersConfig
+		wantErr bool
+	}{
+		{config.SubscribersConfig{}, false},
+		{config.SubscribersConfig{Policy: Block, BufferSize: 1}, false},
+		{config.SubscribersConfig{Policy: "drop-all"}, true},
+		{config.SubscribersConfig{BufferSize: -1}, true},
+		{config.SubscribersConfig{BlockTimeoutMs: -1}, true},
+	}
+	for _, tt := range tests {
+		_, err := newSubscription(tt.cfg, defaults)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newSubscription(%+v) error = %v, want error %t", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBlockWaitsForSubscriber(t *testing.T) {
+	sub, err := newSubscription(config.SubscribersConfig{Policy: Block, BufferSize: 1, BlockTimeoutMs: 10_000}, config.SubscribersConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offerFlows(sub, "1")
+	offered := make(chan struct{})
+	go func() {
+		offerFlows(sub, "2")
+		close(offered)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, want := range []string{"1", "2"} {
+		flow, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flow.Id != want {
+			t.Fatalf("got flow %q, want %q", flow.Id, want)
+		}
+	}
+	<-offered
+	if got := drain(t, sub); len(got) > 0 {
+		t.Errorf("got %v after both flows", got)
+	}
+}
+
+func TestUnmatchedFlowsAreNotDropped(t *testing.T) {
+	sub, err := newSubscription(config.SubscribersConfig{Policy: DropNewest, BufferSize: 1}, config.SubscribersConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.filter, err = filter.Parse(`id ~ "^a"`); err != nil {
+		t.Fatal(err)
+	}
+	offerFlows(sub, "a1", "b1", "b2", "a2", "b3")
+	want := []string{"a1", "gap 1/1"}
+	if got := drain(t, sub); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReplayFromEvictedSeq(t *testing.T) {
+	e := New(config.RetentionConfig{MaxFlows: 3}, config.SubscribersConfig{Policy: DropNewest, BufferSize: 1})
+	for i := 1; i <= 5; i++ {
+		e.AddFlow(&apix.Flow{Id: fmt.Sprint(i)})
+	}
+	tests := []struct {
+		from Cursor
+		want []string
+	}{
+		{Cursor{FromSeq: 1}, []string{"gap 2/2", "3", "4", "5"}},
+		{Cursor{FromSeq: 2}, []string{"gap 1/1", "3", "4", "5"}},
+		{Cursor{FromSeq: 4}, []string{"4", "5"}},
+		{Cursor{FromSeq: 9}, nil},
+		{Cursor{Last: 2}, []string{"4", "5"}},
+		{Cursor{}, nil},
+	}
+	for _, tt := range tests {
+		sub, err := e.Subscribe(config.SubscribersConfig{}, tt.from, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, sub); !slices.Equal(got, tt.want) {
+			t.Errorf("replay from %+v: got %v, want %v", tt.from, got, tt.want)
+		}
+		e.Unsubscribe(sub)
+	}
+
+	// Flows dropped later add to the total reported by the replay gap.
+	sub, err := e.Subscribe(config.SubscribersConfig{}, Cursor{FromSeq: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.AddFlow(&apix.Flow{Id: "6"})
+	e.AddFlow(&apix.Flow{Id: "7"})
+	want := []string{"gap 2/2", "3", "4", "5", "6", "gap 1/3"}
+	if got := drain(t, sub); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
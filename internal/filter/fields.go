@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// field reads a value from a flow, as text or, if number is set, as a
+// number.
+type field struct {
+	text   func(*apix.Flow) string
+	number func(*apix.Flow) float64
+}
+
+func textField(get func(*apix.Flow) string) field {
+	return field{text: get}
+}
+
+func numberField(get func(*apix.Flow) float64) field {
+	return field{number: get}
+}
+
+func boolField(get func(*apix.Flow) bool) field {
+	return numberField(func(f *apix.Flow) float64 {
+		if get(f) {
+			return 1
+		}
+		return 0
+	})
+}
+
+var fields = map[string]field{
+	"id":     textField(func(f *apix.Flow) string { return f.Id }),
+	"method": textField(func(f *apix.Flow) string { return f.GetRequest().GetMethod() }),
+	"url":    textField(func(f *apix.Flow) string { return f.GetRequest().GetUrl() }),
+	"host": textField(func(f *apix.Flow) string {
+		if t := f.GetTunnel(); t != nil {
+			return hostname(t.Host)
+		}
+		return requestURL(f).Hostname()
+	}),
+	"path":              textField(func(f *apix.Flow) string { return requestURL(f).Path }),
+	"query":             textField(func(f *apix.Flow) string { return requestURL(f).RawQuery }),
+	"scheme":            textField(func(f *apix.Flow) string { return requestURL(f).Scheme }),
+	"status":            numberField(func(f *apix.Flow) float64 { return float64(f.GetResponse().GetStatusCode()) }),
+	"duration":          numberField(func(f *apix.Flow) float64 { return float64(f.DurationMs) }),
+	"size":              numberField(func(f *apix.Flow) float64 { return float64(f.GetResponse().GetBodySize()) }),
+	"req_size":          numberField(func(f *apix.Flow) float64 { return float64(f.GetRequest().GetBodySize()) }),
+	"content_type":      textField(func(f *apix.Flow) string { return f.GetResponse().GetHeaders()["Content-Type"] }),
+	"seq":               numberField(func(f *apix.Flow) float64 { return float64(f.Seq) }),
+	"client":            textField(func(f *apix.Flow) string { return f.ClientAddr }),
+	"protocol":          textField(func(f *apix.Flow) string { return f.ClientProtocol }),
+	"upstream_protocol": textField(func(f *apix.Flow) string { return f.UpstreamProtocol }),
+	"route":             textField(func(f *apix.Flow) string { return f.UpstreamRoute }),
+	"user":              textField(func(f *apix.Flow) string { return f.ProxyUser }),
+	"profile":           textField(func(f *apix.Flow) string { return f.NetworkProfile }),
+	"ip":                textField(func(f *apix.Flow) string { return f.RemoteIp }),
+	"tls":               textField(func(f *apix.Flow) string { return f.GetUpstreamTls().GetVersion() }),
+	"error":             textField(func(f *apix.Flow) string { return f.Error }),
+	"fault":             textField(func(f *apix.Flow) string { return f.GetFault().GetKind() }),
+	"local_file":        textField(func(f *apix.Flow) string { return f.LocalFile }),
+	"remote_url":        textField(func(f *apix.Flow) string { return f.RemoteUrl }),
+	"tunnel":            boolField(func(f *apix.Flow) bool { return f.Tunnel != nil }),
+	"open":              boolField(func(f *apix.Flow) bool { return f.Open }),
+}
+
+// lookupField returns a named field. Besides the fixed ones, headers are
+// fields named req.header.<name> and resp.header.<name>.
+func lookupField(name string) (field, bool) {
+	if f, ok := fields[name]; ok {
+		return f, true
+	}
+	if h, ok := strings.CutPrefix(name, "req.header."); ok && h != "" {
+		h = textproto.CanonicalMIMEHeaderKey(h)
+		return textField(func(f *apix.Flow) string { return f.GetRequest().GetHeaders()[h] }), true
+	}
+	if h, ok := strings.CutPrefix(name, "resp.header."); ok && h != "" {
+		h = textproto.CanonicalMIMEHeaderKey(h)
+		return textField(func(f *apix.Flow) string { return f.GetResponse().GetHeaders()[h] }), true
+	}
+	return field{}, false
+}
+
+func requestURL(f *apix.Flow) *url.URL {
+	u, err := url.Parse(f.GetRequest().GetUrl())
+	if err != nil {
+		return &url.URL{}
+	}
+	return u
+}
+
+func hostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
@@ -0,0 +1,134 @@
+// Package filter implements the expression language used to select flows,
+// e.g.
+//
+//	host ~ "api\\.example" && method == "POST" && status >= 400
+//
+// Comparisons take a field on the left and a quoted string or a number on
+// the right. == and != compare exactly, <, <=, > and >= compare numbers or
+// strings in byte order, and ~ and !~ match a regular expression. A field
+// on its own tests for a non-empty string or non-zero number. Conditions
+// combine with &&, || and !, and group with parentheses.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// Filter is a parsed expression.
+type Filter struct {
+	expr string
+	root node
+}
+
+// SyntaxError describes what is wrong with an expression and where.
+type SyntaxError struct {
+	Pos int // column, from 1
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at column %d: %s", e.Pos, e.Msg)
+}
+
+// Parse parses an expression. An empty expression matches every flow.
+func Parse(expr string) (*Filter, error) {
+	p := &parser{lex: lexer{src: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return &Filter{expr: expr}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match reports whether flow satisfies the expression. A nil Filter
+// matches every flow.
+func (f *Filter) Match(flow *apix.Flow) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.match(flow)
+}
+
+func (f *Filter) String() string {
+	return f.expr
+}
+
+type node interface {
+	match(flow *apix.Flow) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(flow *apix.Flow) bool { return n.left.match(flow) && n.right.match(flow) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(flow *apix.Flow) bool { return n.left.match(flow) || n.right.match(flow) }
+
+type notNode struct{ node node }
+
+func (n notNode) match(flow *apix.Flow) bool { return !n.node.match(flow) }
+
+// testNode is a field on its own.
+type testNode struct{ field field }
+
+func (n testNode) match(flow *apix.Flow) bool {
+	if n.field.number != nil {
+		return n.field.number(flow) != 0
+	}
+	return n.field.text(flow) != ""
+}
+
+type compareNode struct {
+	field field
+	op    string
+	text  string
+	num   float64 // set for numeric fields
+	re    *regexp.Regexp
+}
+
+func (n compareNode) match(flow *apix.Flow) bool {
+	if n.re != nil {
+		var s string
+		if n.field.number != nil {
+			s = strconv.FormatFloat(n.field.number(flow), 'f', -1, 64)
+		} else {
+			s = n.field.text(flow)
+		}
+		return n.re.MatchString(s) == (n.op == "~")
+	}
+	if n.field.number != nil {
+		return compare(n.field.number(flow), n.num, n.op)
+	}
+	return compare(n.field.text(flow), n.text, n.op)
+}
+
+func compare[T string | float64](a, b T, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
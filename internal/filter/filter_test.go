@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+var (
+	postFlow = &apix.Flow{
+		Id:  "a1",
+		Seq: 3,
+		Request: &apix.HttpRequest{
+			Method:  "POST",
+			Url:     "https://api.example.com:8443/v1/orders?id=7",
+			Headers: map[string]string{"Content-Type": "application/json", "X-Request-Id": "r-42"},
+		},
+		Response: &apix.HttpResponse{
+			StatusCode: 502,
+			Headers:    map[string]string{"Content-Type": "text/plain"},
+			BodySize:   2048,
+		},
+		DurationMs: 120,
+		Fault:      &apix.Fault{Kind: "status"},
+	}
+	getFlow = &apix.Flow{
+		Id:       "b2",
+		Seq:      1,
+		Request:  &apix.HttpRequest{Method: "GET", Url: "http://www.example.org/"},
+		Response: &apix.HttpResponse{StatusCode: 200, BodySize: 10},
+	}
+	tunnelFlow = &apix.Flow{
+		Id:     "c3",
+		Seq:    2,
+		Tunnel: &apix.Tunnel{Host: "db.internal:5432", Protocol: "CONNECT"},
+		Error:  "connection reset",
+	}
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string // IDs of the flows that match
+	}{
+		{``, []string{"a1", "b2", "c3"}},
+		{`method == "POST"`, []string{"a1"}},
+		{`method != "POST"`, []string{"b2", "c3"}},
+		{`host ~ "api\\.example" && method == "POST" && status >= 400`, []string{"a1"}},
+		{`host == "db.internal"`, []string{"c3"}},
+		{`path == "/v1/orders" && query == "id=7" && scheme == "https"`, []string{"a1"}},
+		{`status < 300`, []string{"b2", "c3"}},
+		{`status >= -1`, []string{"a1", "b2", "c3"}},
+		{`status > 200 || error`, []string{"a1", "c3"}},
+		{`!(status == 200) && !tunnel`, []string{"a1"}},
+		{`status ~ "^5"`, []string{"a1"}},
+		{`url !~ "example"`, []string{"c3"}},
+		{`duration >= 100.5`, []string{"a1"}},
+		{`size <= 10 && size > 0`, []string{"b2"}},
+		{`fault == "status"`, []string{"a1"}},
+		{`tunnel`, []string{"c3"}},
+		{`content_type == "text/plain"`, []string{"a1"}},
+		{`req.header.x-request-id == "r-42"`, []string{"a1"}},
+		{`resp.header.Content-Type ~ "plain"`, []string{"a1"}},
+		{`method == "GET" || method == "POST" && status == 200`, []string{"b2"}},
+		{`(method == "GET" || method == "POST") && status == 502`, []string{"a1"}},
+		{`seq == 2`, []string{"c3"}},
+		{`id == "b2"`, []string{"b2"}},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, flow := range []*apix.Flow{postFlow, getFlow, tunnelFlow} {
+			if f.Match(flow) {
+				got = append(got, flow.Id)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q matched %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNilFilterMatches(t *testing.T) {
+	var f *Filter
+	if !f.Match(getFlow) {
+		t.Error("nil filter did not match")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{`host ==`, 8, "expected a string or number"},
+		{`status == "x"`, 11, "status is a number"},
+		{`host ~ 1`, 8, "quoted regular expression"},
+		{`host ~ "("`, 8, "invalid regular expression"},
+		{`foo == 1`, 1, `unknown field "foo"`},
+		{`method == "GET" &&`, 19, "expected a field"},
+		{`method = 1`, 8, "unexpected '='"},
+		{`"abc`, 1, "unterminated string"},
+		{`(status == 200`, 15, `expected ")"`},
+		{`status == 200 status`, 15, `unexpected "status"`},
+		{`status == 1.2.3`, 11, "invalid number"},
+		{`== 1`, 1, "expected a field"},
+		{`host == - 1`, 9, "unexpected '-'"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("Parse(%q) = %v, want a syntax error", tt.expr, err)
+			continue
+		}
+		if serr.Pos != tt.pos || !strings.Contains(serr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) = %v, want %q at column %d", tt.expr, err, tt.msg, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// Order returns a comparison of flows by one of the fields, for sorting.
+// The order is descending if key starts with "-"; ties are in stream order.
+func Order(key string) (func(a, b *apix.Flow) int, error) {
+	name, desc := strings.CutPrefix(key, "-")
+	f, ok := lookupField(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", name)
+	}
+	return func(a, b *apix.Flow) int {
+		var c int
+		if f.number != nil {
+			c = cmp.Compare(f.number(a), f.number(b))
+		} else {
+			c = strings.Compare(f.text(a), f.text(b))
+		}
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.Seq, b.Seq)
+		}
+		return c
+	}, nil
+}
@@ -0,0 +1,41 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"seq", []string{"b2", "c3", "a1"}},
+		{"-seq", []string{"a1", "c3", "b2"}},
+		{"status", []string{"c3", "b2", "a1"}},
+		{"-size", []string{"a1", "b2", "c3"}},
+		{"method", []string{"c3", "b2", "a1"}},
+	}
+	for _, tt := range tests {
+		order, err := Order(tt.key)
+		if err != nil {
+			t.Errorf("Order(%q): %v", tt.key, err)
+			continue
+		}
+		flows := []*apix.Flow{postFlow, getFlow, tunnelFlow}
+		slices.SortStableFunc(flows, order)
+		var got []string
+		for _, flow := range flows {
+			got = append(got, flow.Id)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("sorted by %q: %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	if _, err := Order("-bogus"); err == nil {
+		t.Error("Order(\"-bogus\") did not fail")
+	}
+}
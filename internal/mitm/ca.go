@@ -0,0 +1,246 @@
+package mitm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 365 * 24 * time.Hour
+
+	// DefaultCacheSize is used when no leaf cache size is configured.
+	DefaultCacheSize = 1000
+)
+
+// CA is the root certificate authority used to mint leaf certificates for
+// intercepted hosts.
+type CA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	leafKey *ecdsa.PrivateKey
+	cache   *certCache
+}
+
+// DefaultPaths returns the certificate and key locations used when the
+// configuration does not name any.
+func DefaultPaths() (certFile, keyFile string) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	dir = filepath.Join(dir, "apix")
+	return filepath.Join(dir, "apix-ca.pem"), filepath.Join(dir, "apix-ca-key.pem")
+}
+
+// LoadOrCreateCA loads the root CA from certFile and keyFile. If neither
+// file exists a new root CA is generated and written there; if only one
+// does, it is an error, so a certificate clients already trust is never
+// replaced.
+func LoadOrCreateCA(certFile, keyFile string, cacheSize int) (*CA, error) {
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+
+	certExists, err := fileExists(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyExists, err := fileExists(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var cert *x509.Certificate
+	var key crypto.Signer
+	switch {
+	case certExists && keyExists:
+		cert, key, err = loadCA(certFile, keyFile)
+	case certExists:
+		err = fmt.Errorf("CA certificate %s exists but its key %s does not", certFile, keyFile)
+	case keyExists:
+		err = fmt.Errorf("CA key %s exists but its certificate %s does not", keyFile, certFile)
+	default:
+		cert, key, err = createCA(certFile, keyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate leaf key: %w", err)
+	}
+
+	return &CA{
+		cert:    cert,
+		key:     key,
+		leafKey: leafKey,
+		cache:   newCertCache(cacheSize),
+	}, nil
+}
+
+func fileExists(name string) (bool, error) {
+	_, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Certificate returns the root certificate clients need to trust.
+func (ca *CA) Certificate() *x509.Certificate {
+	return ca.cert
+}
+
+// CertFor returns a leaf certificate for host, minting and caching one if
+// needed. host may be a DNS name or an IP address.
+func (ca *CA) CertFor(host string) (*tls.Certificate, error) {
+	if cert, ok := ca.cache.get(host); ok {
+		return cert, nil
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("generate serial: %w", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host, Organization: []string{"APiX"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &ca.leafKey.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("sign certificate for %s: %w", host, err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  ca.leafKey,
+		Leaf:        leaf,
+	}
+	ca.cache.add(host, cert)
+	return cert, nil
+}
+
+// TLSConfig returns a server configuration that presents a leaf for the
+// SNI name of each handshake, falling back to defaultHost when the client
+// sends none.
+func (ca *CA) TLSConfig(defaultHost string) *tls.Config {
+	return &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			host := hello.ServerName
+			if host == "" {
+				host = defaultHost
+			}
+			return ca.CertFor(host)
+		},
+		NextProtos: []string{"http/1.1"},
+	}
+}
+
+func loadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse CA key pair: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported CA key type %T", pair.PrivateKey)
+	}
+	return cert, key, nil
+}
+
+func createCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate CA key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate serial: %w", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "APiX Root CA", Organization: []string{"APiX"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("self-sign CA: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(certFile, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	return os.WriteFile(path, data, perm)
+}
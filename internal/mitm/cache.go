@@ -0,0 +1,63 @@
+package mitm
+
+import (
+	"container/list"
+	"crypto/tls"
+	"sync"
+	"time"
+)
+
+// certCache is a fixed-size LRU of minted leaf certificates keyed by host.
+type certCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	host string
+	cert *tls.Certificate
+}
+
+func newCertCache(size int) *certCache {
+	return &certCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *certCache) get(host string) (*tls.Certificate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[host]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	// Re-mint certificates that are about to expire.
+	if time.Until(entry.cert.Leaf.NotAfter) < 24*time.Hour {
+		c.order.Remove(el)
+		delete(c.entries, host)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.cert, true
+}
+
+func (c *certCache) add(host string, cert *tls.Certificate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[host]; ok {
+		el.Value.(*cacheEntry).cert = cert
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[host] = c.order.PushFront(&cacheEntry{host: host, cert: cert})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).host)
+	}
+}
@@ -0,0 +1,151 @@
+package netsim
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrReset is returned by reads and writes of a connection the simulator
+// reset.
+var ErrReset = errors.New("netsim: connection reset by simulated network")
+
+// conn applies a profile to a connection. Latency is added to the first
+// read after each write, which models the round trip of a request and its
+// response; bandwidth is enforced per direction.
+type conn struct {
+	net.Conn
+	sim  *Simulator
+	addr string
+
+	turn     atomic.Bool // the next read starts a response
+	reset    atomic.Bool
+	down, up limiter
+}
+
+func newConn(c net.Conn, sim *Simulator, addr string) *conn {
+	nc := &conn{Conn: c, sim: sim, addr: addr}
+	nc.turn.Store(true)
+	return nc
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	p, ok := c.sim.ProfileFor(c.addr)
+	if ok {
+		if c.maybeReset(p) {
+			return 0, ErrReset
+		}
+		if p.DownloadKbps > 0 {
+			b = b[:min(len(b), chunkSize(p.DownloadKbps))]
+		}
+	}
+	n, err := c.Conn.Read(b)
+	if n == 0 {
+		return n, err
+	}
+	// Reads on idle keep-alive connections block for long, so the profile
+	// is looked up again for what arrived.
+	if p, ok = c.sim.ProfileFor(c.addr); ok {
+		if c.turn.Swap(false) {
+			time.Sleep(delay(p))
+		}
+		c.down.wait(n, p.DownloadKbps)
+	} else {
+		c.turn.Store(false)
+	}
+	return n, err
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	c.turn.Store(true)
+	p, ok := c.sim.ProfileFor(c.addr)
+	if !ok {
+		return c.Conn.Write(b)
+	}
+	if p.UploadKbps <= 0 {
+		if c.maybeReset(p) {
+			return 0, ErrReset
+		}
+		return c.Conn.Write(b)
+	}
+
+	written := 0
+	for written < len(b) {
+		if c.maybeReset(p) {
+			return written, ErrReset
+		}
+		chunk := b[written:min(len(b), written+chunkSize(p.UploadKbps))]
+		c.up.wait(len(chunk), p.UploadKbps)
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (c *conn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// maybeReset aborts the connection with a TCP reset with the profile's
+// probability.
+func (c *conn) maybeReset(p Profile) bool {
+	if c.reset.Load() {
+		return true
+	}
+	if p.ResetProbability <= 0 || rand.Float64() >= p.ResetProbability {
+		return false
+	}
+	if c.reset.CompareAndSwap(false, true) {
+		if tcp, ok := c.Conn.(*net.TCPConn); ok {
+			tcp.SetLinger(0)
+		}
+		c.Conn.Close()
+	}
+	return true
+}
+
+// delay returns the latency for one round trip.
+func delay(p Profile) time.Duration {
+	d := p.Latency
+	if p.Jitter > 0 {
+		d += rand.N(p.Jitter)
+	}
+	return d
+}
+
+// chunkSize keeps transfers to about a tenth of a second's worth of bytes
+// so throttling stays smooth.
+func chunkSize(kbps int64) int {
+	return int(max(kbps*1000/8/10, 512))
+}
+
+// limiter spaces out transfers to stay under a rate.
+type limiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *limiter) wait(n int, kbps int64) {
+	if kbps <= 0 {
+		return
+	}
+	d := time.Duration(int64(n) * 8 * int64(time.Second) / (kbps * 1000))
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(d)
+	sleep := l.next.Sub(now)
+	l.mu.Unlock()
+	time.Sleep(sleep)
+}
@@ -0,0 +1,153 @@
+// Package netsim simulates poor network conditions on proxied connections:
+// limited bandwidth, added latency with jitter and random connection
+// resets, selected per host or globally through named profiles.
+package netsim
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/utils"
+)
+
+// Profile describes a simulated network. Zero values leave that aspect of
+// the connection untouched.
+type Profile struct {
+	Name         string
+	DownloadKbps int64
+	UploadKbps   int64
+	Latency      time.Duration // added once per round trip
+	Jitter       time.Duration // random extra latency up to this much
+	// ResetProbability is the chance that any read or write resets the
+	// connection.
+	ResetProbability float64
+}
+
+// builtinProfiles are available without configuration.
+var builtinProfiles = []Profile{
+	{Name: "3G", DownloadKbps: 1600, UploadKbps: 768, Latency: 150 * time.Millisecond, Jitter: 50 * time.Millisecond},
+	{Name: "edge", DownloadKbps: 240, UploadKbps: 200, Latency: 400 * time.Millisecond, Jitter: 100 * time.Millisecond},
+	{Name: "satellite", DownloadKbps: 10000, UploadKbps: 2000, Latency: 600 * time.Millisecond, Jitter: 40 * time.Millisecond},
+	{Name: "flaky", DownloadKbps: 4000, UploadKbps: 1000, Latency: 80 * time.Millisecond, Jitter: 200 * time.Millisecond, ResetProbability: 0.02},
+}
+
+// Assignment applies a profile to the hosts matching Host, a glob pattern
+// or host:port. An empty Host is the global assignment.
+type Assignment struct {
+	Host    string
+	Profile string
+}
+
+// Simulator holds the known profiles and which of them apply where. It can
+// be reconfigured at runtime; wrapped connections pick up changes on their
+// next read or write.
+type Simulator struct {
+	mu          sync.RWMutex
+	profiles    map[string]Profile
+	global      string
+	assignments []Assignment // per host, first match wins
+}
+
+func New(cfg config.NetworkConfig) *Simulator {
+	s := &Simulator{profiles: make(map[string]Profile)}
+	for _, p := range builtinProfiles {
+		s.profiles[p.Name] = p
+	}
+	for _, p := range cfg.Profiles {
+		s.profiles[p.Name] = Profile{
+			Name:             p.Name,
+			DownloadKbps:     p.DownloadKbps,
+			UploadKbps:       p.UploadKbps,
+			Latency:          time.Duration(p.LatencyMs) * time.Millisecond,
+			Jitter:           time.Duration(p.JitterMs) * time.Millisecond,
+			ResetProbability: p.ResetProbability,
+		}
+	}
+	if err := s.Set("", cfg.Profile); err != nil {
+		log.Printf("Ignoring network profile: %v", err)
+	}
+	for _, rule := range cfg.Hosts {
+		for _, host := range rule.Hosts {
+			if err := s.Set(host, rule.Profile); err != nil {
+				log.Printf("Ignoring network profile for %s: %v", host, err)
+			}
+		}
+	}
+	return s
+}
+
+// Profiles returns all known profiles sorted by name.
+func (s *Simulator) Profiles() []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		profiles = append(profiles, p)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+	return profiles
+}
+
+// Assignments returns the global assignment, if any, followed by the
+// per-host ones in matching order.
+func (s *Simulator) Assignments() []Assignment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Assignment
+	if s.global != "" {
+		out = append(out, Assignment{Profile: s.global})
+	}
+	return append(out, s.assignments...)
+}
+
+// Set applies profile to host, or globally when host is empty. An empty
+// profile removes the assignment.
+func (s *Simulator) Set(host, profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[profile]; profile != "" && !ok {
+		return fmt.Errorf("unknown network profile %q", profile)
+	}
+	if host == "" {
+		s.global = profile
+		return nil
+	}
+	i := slices.IndexFunc(s.assignments, func(a Assignment) bool { return a.Host == host })
+	switch {
+	case profile == "" && i >= 0:
+		s.assignments = slices.Delete(s.assignments, i, i+1)
+	case profile == "":
+	case i >= 0:
+		s.assignments[i].Profile = profile
+	default:
+		s.assignments = append(s.assignments, Assignment{Host: host, Profile: profile})
+	}
+	return nil
+}
+
+// ProfileFor returns the profile that applies to connections to addr.
+func (s *Simulator) ProfileFor(addr string) (Profile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name := s.global
+	for _, a := range s.assignments {
+		if utils.MatchHost(a.Host, addr) {
+			name = a.Profile
+			break
+		}
+	}
+	p, ok := s.profiles[name]
+	return p, ok
+}
+
+// Conn wraps a connection to addr so it is subject to the profile that
+// applies to addr at the time of each read or write.
+func (s *Simulator) Conn(c net.Conn, addr string) net.Conn {
+	return newConn(c, s, addr)
+}
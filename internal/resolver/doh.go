@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dohTimeout     = 10 * time.Second
+	dohMaxResponse = 64 << 10
+	// dohMaxTTL bounds how long answers are cached, whatever their TTL.
+	dohMaxTTL = 5 * time.Minute
+)
+
+// dohClient resolves names over DNS-over-HTTPS (RFC 8484) and caches the
+// answers for their TTL. Expired answers are dropped whenever a new one is
+// cached.
+type dohClient struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]dohEntry
+}
+
+type dohEntry struct {
+	addrs   []netip.Addr
+	expires time.Time
+}
+
+func newDoHClient(url string) *dohClient {
+	return &dohClient{
+		url:    url,
+		client: &http.Client{Timeout: dohTimeout},
+		cache:  make(map[string]dohEntry),
+	}
+}
+
+func (c *dohClient) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	type answer struct {
+		addrs []netip.Addr
+		ttl   time.Duration
+		err   error
+	}
+	types := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	answers := make([]answer, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a := &answers[i]
+			a.addrs, a.ttl, a.err = c.query(ctx, host, t)
+		}()
+	}
+	wg.Wait()
+
+	// A lookup only fails if every query does, so names that merely break
+	// on AAAA still resolve. Partial answers are not cached.
+	var addrs []netip.Addr
+	var err error
+	ttl := dohMaxTTL
+	for _, a := range answers {
+		if a.err != nil {
+			err = cmp.Or(err, a.err)
+			continue
+		}
+		addrs = append(addrs, a.addrs...)
+		if len(a.addrs) > 0 {
+			ttl = min(ttl, a.ttl)
+		}
+	}
+	switch {
+	case len(addrs) == 0 && err != nil:
+		return nil, &net.DNSError{Err: err.Error(), Name: host, Server: c.url}
+	case len(addrs) == 0:
+		return nil, &net.DNSError{Err: "no such host", Name: host, Server: c.url, IsNotFound: true}
+	case err != nil:
+		return addrs, nil
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	maps.DeleteFunc(c.cache, func(_ string, e dohEntry) bool { return !now.Before(e.expires) })
+	c.cache[host] = dohEntry{addrs: addrs, expires: now.Add(ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// query asks for the records of type t for host and returns the addresses
+// in the answer with their lowest TTL.
+func (c *dohClient) query(ctx context.Context, host string, t dnsmessage.Type) ([]netip.Addr, time.Duration, error) {
+	if !strings.HasSuffix(host, ".") {
+		host += "."
+	}
+	name, err := dnsmessage.NewName(host)
+	if err != nil {
+		return nil, 0, err
+	}
+	// The ID stays zero so responses are cacheable (RFC 8484, section 4.1).
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: t, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("DoH server answered %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dohMaxResponse))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := msg.Unpack(body); err != nil {
+		return nil, 0, err
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return nil, 0, fmt.Errorf("DoH server answered %s", msg.RCode)
+	}
+	var addrs []netip.Addr
+	ttl := dohMaxTTL
+	for _, rr := range msg.Answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, netip.AddrFrom4(body.A))
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, netip.AddrFrom16(body.AAAA))
+		default:
+			continue
+		}
+		ttl = min(ttl, time.Duration(rr.Header.TTL)*time.Second)
+	}
+	return addrs, ttl, nil
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDoH answers A queries with 192.0.2.1 and a TTL of ttl seconds, and
+// fails the query types in failing.
+func fakeDoH(t *testing.T, ttl uint32, failing ...dnsmessage.Type) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg dnsmessage.Message
+		if err := msg.Unpack(body); err != nil || len(msg.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		q := msg.Questions[0]
+		if slices.Contains(failing, q.Type) {
+			http.Error(w, "upstream timeout", http.StatusBadGateway)
+			return
+		}
+		msg.Response = true
+		if q.Type == dnsmessage.TypeA {
+			msg.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: ttl},
+				Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+			}}
+		}
+		packed, err := msg.Pack()
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoHLookup(t *testing.T) {
+	want := []netip.Addr{netip.MustParseAddr("192.0.2.1")}
+	tests := []struct {
+		name    string
+		failing []dnsmessage.Type
+		wantErr bool
+	}{
+		{"both answer", nil, false},
+		{"AAAA fails", []dnsmessage.Type{dnsmessage.TypeAAAA}, false},
+		{"both fail", []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDoHClient(fakeDoH(t, 60, tt.failing...).URL)
+			addrs, err := c.lookup(context.Background(), "example.com")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", addrs)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(addrs, want) {
+				t.Errorf("got %v, %v, want %v", addrs, err, want)
+			}
+		})
+	}
+}
+
+func TestDoHCacheDropsExpiredAnswers(t *testing.T) {
+	c := newDoHClient(fakeDoH(t, 0).URL)
+	for _, host := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		if _, err := c.lookup(context.Background(), host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.cache) != 1 {
+		t.Errorf("cache holds %d answers, want only the latest", len(c.cache))
+	}
+}
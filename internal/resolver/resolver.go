@@ -0,0 +1,185 @@
+// Package resolver looks up upstream hosts for the proxy. Static overrides,
+// exact or wildcard, take precedence, much like entries in /etc/hosts;
+// other names go to the system resolver, to configured DNS servers or to a
+// DNS-over-HTTPS endpoint.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mnafshin/apix/internal/config"
+)
+
+// Override sends connections for Host, a name or a glob pattern such as
+// "*.example.com", to Target, an IP address or another host name.
+type Override struct {
+	Host   string
+	Target string
+}
+
+// Resolver resolves upstream host names. Overrides can be changed at
+// runtime and apply to the next connection.
+type Resolver struct {
+	mu        sync.RWMutex
+	overrides []Override // exact names are matched before patterns
+
+	dns     *net.Resolver
+	servers []string
+	next    atomic.Uint32 // server for the next query
+	doh     *dohClient
+}
+
+func New(cfg config.DNSConfig) *Resolver {
+	r := &Resolver{dns: net.DefaultResolver}
+	for _, s := range cfg.Servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, "53")
+		}
+		r.servers = append(r.servers, s)
+	}
+	if len(r.servers) > 0 {
+		r.dns = &net.Resolver{PreferGo: true, Dial: r.dialServer}
+	}
+	if cfg.DoH != "" {
+		r.doh = newDoHClient(cfg.DoH)
+	}
+	for _, o := range cfg.Overrides {
+		if err := r.SetOverride(o.Host, o.Target); err != nil {
+			log.Printf("Ignoring host override for %s: %v", o.Host, err)
+		}
+	}
+	return r
+}
+
+// Source describes where names without an override are resolved.
+func (r *Resolver) Source() string {
+	switch {
+	case r.doh != nil:
+		return r.doh.url
+	case len(r.servers) > 0:
+		return strings.Join(r.servers, ", ")
+	}
+	return "system"
+}
+
+// Overrides returns the overrides in matching order.
+func (r *Resolver) Overrides() []Override {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.overrides)
+}
+
+// SetOverride sends host to target, replacing any override for host. An
+// empty target removes the override.
+func (r *Resolver) SetOverride(host, target string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return fmt.Errorf("override has no host")
+	}
+	if _, err := path.Match(host, ""); err != nil || strings.ContainsAny(host, " \t/:") {
+		return fmt.Errorf("invalid host pattern %q", host)
+	}
+	if target != "" && !validTarget(target) {
+		return fmt.Errorf("override target %q is neither an IP address nor a host name", target)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.IndexFunc(r.overrides, func(o Override) bool { return o.Host == host })
+	switch {
+	case target == "" && i >= 0:
+		r.overrides = slices.Delete(r.overrides, i, i+1)
+	case target == "":
+	case i >= 0:
+		r.overrides[i].Target = target
+	default:
+		r.overrides = append(r.overrides, Override{Host: host, Target: target})
+		slices.SortStableFunc(r.overrides, func(a, b Override) int {
+			return compareBool(isPattern(a.Host), isPattern(b.Host))
+		})
+	}
+	return nil
+}
+
+// Override returns the target host is overridden to, if any.
+func (r *Resolver) Override(host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.overrides {
+		if ok, _ := path.Match(o.Host, host); ok {
+			return o.Target, true
+		}
+	}
+	return "", false
+}
+
+// LookupHost returns the addresses to connect to for host, applying
+// overrides. IP literals are returned as they are.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{ip}, nil
+	}
+	if target, ok := r.Override(host); ok {
+		if ip, err := netip.ParseAddr(target); err == nil {
+			return []netip.Addr{ip}, nil
+		}
+		// An alias is looked up as it is, without applying overrides again.
+		host = target
+	}
+	if r.doh != nil {
+		return r.doh.lookup(ctx, host)
+	}
+	addrs, err := r.dns.LookupNetIP(ctx, "ip", host)
+	if dnsErr, ok := err.(*net.DNSError); ok && len(r.servers) > 0 {
+		// The resolver reports the system's server it was asked to dial.
+		dnsErr.Server = r.Source()
+	}
+	for i, a := range addrs {
+		addrs[i] = a.Unmap()
+	}
+	return addrs, err
+}
+
+// dialServer sends each query to the next configured DNS server in turn,
+// so the resolver's retries reach the other servers when one is down.
+func (r *Resolver) dialServer(ctx context.Context, network, _ string) (net.Conn, error) {
+	i := r.next.Add(1) - 1
+	var d net.Dialer
+	return d.DialContext(ctx, network, r.servers[int(i)%len(r.servers)])
+}
+
+func validTarget(target string) bool {
+	if _, err := netip.ParseAddr(target); err == nil {
+		return true
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(target, "."), ".") {
+		if label == "" || len(label) > 63 || strings.Trim(label, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isPattern(host string) bool {
+	return strings.ContainsAny(host, "*?[")
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"log"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+
+	"github.com/mnafshin/apix/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// proxyUserKey carries the authenticated proxy user of a request.
+type proxyUserKey struct{}
+
+// proxyAuth guards the HTTP proxy listener with a client allowlist and
+// Basic authentication. Requests decrypted from a tunnel inherit the user
+// of its CONNECT request instead of authenticating again.
+type proxyAuth struct {
+	next    http.Handler
+	realm   string
+	users   *credentials
+	allowed []netip.Prefix
+}
+
+// credentials checks usernames and passwords against bcrypt hashes. Both
+// the HTTP and the SOCKS listener authenticate with them.
+type credentials struct {
+	hashes map[string][]byte // by username
+
+	// verified remembers credentials that passed bcrypt, which is too slow
+	// to run on every request of a busy client.
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]bool
+}
+
+// newCredentials returns nil when there are no users.
+func newCredentials(users []config.ProxyAuthUser) *credentials {
+	if len(users) == 0 {
+		return nil
+	}
+	c := &credentials{
+		hashes:   make(map[string][]byte),
+		verified: make(map[[sha256.Size]byte]bool),
+	}
+	for _, u := range users {
+		c.hashes[u.Username] = []byte(u.PasswordHash)
+	}
+	return c
+}
+
+// check reports whether password is the one of username.
+func (c *credentials) check(username, password string) bool {
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	c.mu.Lock()
+	ok := c.verified[key]
+	c.mu.Unlock()
+	if ok {
+		return true
+	}
+
+	hash, ok := c.hashes[username]
+	if !ok || bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return false
+	}
+	c.mu.Lock()
+	c.verified[key] = true
+	c.mu.Unlock()
+	return true
+}
+
+// newProxyAuth wraps next, or returns nil when cfg restricts nothing.
+func newProxyAuth(cfg config.ProxyAuthConfig, next http.Handler) *proxyAuth {
+	if len(cfg.Users) == 0 && len(cfg.AllowedClients) == 0 {
+		return nil
+	}
+	a := &proxyAuth{next: next, realm: cfg.Realm, users: newCredentials(cfg.Users)}
+	for _, c := range cfg.AllowedClients {
+		prefix, err := netip.ParsePrefix(c)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(c)
+			if aerr != nil {
+				log.Printf("Ignoring invalid allowed client %q: %v", c, err)
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		a.allowed = append(a.allowed, prefix)
+	}
+	return a
+}
+
+func (a *proxyAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !a.clientAllowed(r.RemoteAddr) {
+		log.Printf("Rejected proxy request from %s: client not allowed", r.RemoteAddr)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if a.users != nil {
+		user, ok := a.authenticate(r.Header.Get("Proxy-Authorization"))
+		if !ok {
+			log.Printf("Rejected proxy request from %s: authentication required", r.RemoteAddr)
+			w.Header().Set("Proxy-Authenticate", "Basic realm="+strconv.Quote(a.realm)+`, charset="UTF-8"`)
+			http.Error(w, "Proxy Authentication Required", http.StatusProxyAuthRequired)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), proxyUserKey{}, user))
+	}
+	a.next.ServeHTTP(w, r)
+}
+
+func (a *proxyAuth) clientAllowed(remoteAddr string) bool {
+	if len(a.allowed) == 0 {
+		return true
+	}
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := addrPort.Addr().Unmap()
+	for _, prefix := range a.allowed {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate checks Basic credentials from a Proxy-Authorization header
+// and returns the user they belong to.
+func (a *proxyAuth) authenticate(header string) (string, bool) {
+	// Proxy-Authorization has the same syntax as Authorization.
+	r := &http.Request{Header: http.Header{"Authorization": {header}}}
+	username, password, ok := r.BasicAuth()
+	if !ok || !a.users.check(username, password) {
+		return "", false
+	}
+	return username, true
+}
+
+// proxyUser returns the proxy user a request was authenticated as, either
+// directly or through the tunnel it arrived in.
+func proxyUser(r *http.Request) string {
+	if user, ok := r.Context().Value(proxyUserKey{}).(string); ok {
+		return user
+	}
+	if t, ok := r.Context().Value(tunnelKey{}).(tunnelTarget); ok {
+		return t.user
+	}
+	return ""
+}
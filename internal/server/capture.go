@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mnafshin/apix/internal/config"
+)
+
+// capturedBody is the outcome of recording one request or response body.
+type capturedBody struct {
+	data      []byte
+	file      string
+	size      int64
+	truncated bool
+}
+
+// bodyRecorder receives a copy of a body as it streams through the proxy.
+// It keeps at most maxBytes, moving the capture to a file in spillDir once
+// it grows past memBytes. Writes never fail so recording problems cannot
+// disturb the proxied stream. The upstream transport may still be sending
+// a request body when the flow is recorded, so writes after finish are
+// dropped.
+type bodyRecorder struct {
+	mu        sync.Mutex
+	done      bool
+	maxBytes  int64
+	memBytes  int64
+	spillDir  string
+	buf       bytes.Buffer
+	file      *os.File
+	stored    int64
+	size      int64
+	truncated bool
+}
+
+func newBodyRecorder(cfg config.CaptureConfig) *bodyRecorder {
+	dir := cfg.SpillDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "apix-bodies")
+	}
+	return &bodyRecorder{maxBytes: cfg.MaxBodyBytes, memBytes: cfg.MemoryBodyBytes, spillDir: dir}
+}
+
+func (b *bodyRecorder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if b.done {
+		return n, nil
+	}
+	b.size += int64(n)
+
+	if room := b.maxBytes - b.stored; int64(len(p)) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	if b.file == nil && b.stored+int64(len(p)) > b.memBytes {
+		b.spill()
+	}
+	if b.file != nil {
+		if _, err := b.file.Write(p); err != nil {
+			// Keep what made it to disk and stop recording.
+			log.Printf("Failed to write captured body to %s: %v", b.file.Name(), err)
+			b.maxBytes = b.stored
+			b.truncated = true
+			return n, nil
+		}
+	} else {
+		b.buf.Write(p)
+	}
+	b.stored += int64(len(p))
+	return n, nil
+}
+
+// spill moves the in-memory capture to a new file. On failure the recorder
+// keeps buffering in memory.
+func (b *bodyRecorder) spill() {
+	if err := os.MkdirAll(b.spillDir, 0o700); err != nil {
+		log.Printf("Failed to create body spill directory: %v", err)
+		return
+	}
+	f, err := os.CreateTemp(b.spillDir, "body-*")
+	if err != nil {
+		log.Printf("Failed to create body spill file: %v", err)
+		return
+	}
+	if _, err := f.Write(b.buf.Bytes()); err != nil {
+		log.Printf("Failed to write captured body to %s: %v", f.Name(), err)
+		f.Close()
+		os.Remove(f.Name())
+		return
+	}
+	b.buf.Reset()
+	b.file = f
+}
+
+func (b *bodyRecorder) finish() capturedBody {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	body := capturedBody{size: b.size, truncated: b.truncated}
+	if b.file != nil {
+		b.file.Close()
+		body.file = b.file.Name()
+		return body
+	}
+	if b.buf.Len() > 0 {
+		body.data = bytes.Clone(b.buf.Bytes())
+	}
+	return body
+}
+
+// teeBody records everything read from body into rec.
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+func newTeeBody(body io.ReadCloser, rec *bodyRecorder) io.ReadCloser {
+	return &teeBody{Reader: io.TeeReader(body, rec), Closer: body}
+}
+
+// streamBody copies src to w, flushing after every read so the client sees
+// bytes as soon as the upstream produces them, and mirrors them into rec.
+// rec must not fail, like bodyRecorder.
+func streamBody(w http.ResponseWriter, src io.Reader, rec io.Writer) error {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			rec.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
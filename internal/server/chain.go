@@ -0,0 +1,239 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/utils"
+	netproxy "golang.org/x/net/proxy"
+)
+
+// routeDirect names connections that are not chained through another proxy.
+const routeDirect = "direct"
+
+// proxyRoute is a parsed upstream proxy rule. A nil proxy URL dials
+// directly, so rules can exempt hosts from a broader catch-all.
+type proxyRoute struct {
+	hosts []string
+	proxy *url.URL
+	name  string // proxy URL without credentials, recorded on flows
+}
+
+// socksDialer is implemented by the SOCKS5 dialer of x/net/proxy. Using it
+// on a connection we dialed ourselves keeps the raw TCP connection, which
+// tunnels need for half-closes.
+type socksDialer interface {
+	DialWithConn(ctx context.Context, c net.Conn, network, address string) (net.Addr, error)
+}
+
+func newProxyRoutes(cfg config.UpstreamProxyConfig) []*proxyRoute {
+	var routes []*proxyRoute
+	for _, rule := range cfg.Rules {
+		route := &proxyRoute{hosts: rule.Hosts, name: routeDirect}
+		if rule.Proxy != "" && rule.Proxy != routeDirect {
+			u, err := url.Parse(rule.Proxy)
+			if err != nil || u.Host == "" {
+				log.Printf("Ignoring upstream proxy rule with invalid proxy %q", rule.Proxy)
+				continue
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5":
+			default:
+				log.Printf("Ignoring upstream proxy rule with unsupported scheme %q", u.Scheme)
+				continue
+			}
+			route.proxy = u
+			route.name = u.Scheme + "://" + u.Host
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// routeFor returns the first rule matching addr, or nil to dial directly.
+func (u *upstream) routeFor(addr string) *proxyRoute {
+	for _, route := range u.routes {
+		for _, pattern := range route.hosts {
+			if utils.MatchHost(pattern, addr) {
+				return route
+			}
+		}
+	}
+	return nil
+}
+
+// routeName describes how connections to addr are made.
+func (u *upstream) routeName(addr string) string {
+	if route := u.routeFor(addr); route != nil {
+		return route.name
+	}
+	return routeDirect
+}
+
+// dial opens a TCP connection to addr, through an upstream proxy if a rule
+// says so, subject to the simulated network conditions for addr.
+func (u *upstream) dial(ctx context.Context, addr string) (net.Conn, error) {
+	u.mu.Lock()
+	if conns := u.adopted[addr]; len(conns) > 0 {
+		conn := conns[0]
+		u.adopted[addr] = conns[1:]
+		if len(conns) == 1 {
+			delete(u.adopted, addr)
+		}
+		u.mu.Unlock()
+		return conn, nil
+	}
+	u.mu.Unlock()
+
+	conn, ip, err := u.dialRoute(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &resolvedConn{Conn: u.network.Conn(conn, addr), ip: ip}, nil
+}
+
+// adopt hands conn, dialed to addr, to the next dial to addr, so a target
+// connected to before a tunnel is intercepted is not connected to twice.
+// The returned function closes conn if nothing took it by then.
+func (u *upstream) adopt(addr string, conn net.Conn) func() {
+	u.mu.Lock()
+	u.adopted[addr] = append(u.adopted[addr], conn)
+	u.mu.Unlock()
+	return func() {
+		u.mu.Lock()
+		conns := u.adopted[addr]
+		i := slices.Index(conns, conn)
+		if i >= 0 {
+			u.adopted[addr] = slices.Delete(conns, i, i+1)
+			if len(u.adopted[addr]) == 0 {
+				delete(u.adopted, addr)
+			}
+		}
+		u.mu.Unlock()
+		if i >= 0 {
+			conn.Close()
+		}
+	}
+}
+
+// dialRoute connects to addr and returns the IP it was resolved to, which
+// is empty when a chained proxy resolves it.
+func (u *upstream) dialRoute(ctx context.Context, addr string) (net.Conn, string, error) {
+	route := u.routeFor(addr)
+	if route == nil || route.proxy == nil {
+		return u.dialDirect(ctx, addr)
+	}
+
+	// Host overrides still apply; other names are left to the proxy.
+	ip := ""
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if _, ok := u.resolver.Override(host); ok {
+			addrs, err := u.resolver.LookupHost(ctx, host)
+			if err != nil {
+				return nil, "", err
+			}
+			ip = addrs[0].String()
+			addr = net.JoinHostPort(ip, port)
+		}
+	}
+
+	proxyAddr := route.proxy.Host
+	if route.proxy.Port() == "" {
+		port := "1080"
+		switch route.proxy.Scheme {
+		case "http":
+			port = "8080"
+		case "https":
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(route.proxy.Hostname(), port)
+	}
+	conn, err := u.dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, "", fmt.Errorf("upstream proxy %s: %w", route.name, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(u.dialer.Timeout))
+	}
+	if route.proxy.Scheme == "socks5" {
+		err = socksConnect(ctx, conn, route.proxy, proxyAddr, addr)
+	} else {
+		if route.proxy.Scheme == "https" {
+			tlsConn := tls.Client(conn, &tls.Config{ServerName: route.proxy.Hostname()})
+			if err = tlsConn.HandshakeContext(ctx); err == nil {
+				conn = tlsConn
+			}
+		}
+		if err == nil {
+			var tunnel net.Conn
+			if tunnel, err = httpConnect(conn, route.proxy, addr); err == nil {
+				conn = tunnel
+			}
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, "", fmt.Errorf("upstream proxy %s: %w", route.name, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, ip, nil
+}
+
+func socksConnect(ctx context.Context, conn net.Conn, proxyURL *url.URL, proxyAddr, addr string) error {
+	var auth *netproxy.Auth
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		auth = &netproxy.Auth{User: user.Username(), Password: password}
+	}
+	d, err := netproxy.SOCKS5("tcp", proxyAddr, auth, nil)
+	if err != nil {
+		return err
+	}
+	_, err = d.(socksDialer).DialWithConn(ctx, conn, "tcp", addr)
+	return err
+}
+
+// httpConnect asks the HTTP proxy on conn to open a tunnel to addr.
+func httpConnect(conn net.Conn, proxyURL *url.URL, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT %s: %s", addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+const tunnelDialTimeout = 10 * time.Second
+
+// recordTypeHandshake is the first byte of every TLS ClientHello.
+const recordTypeHandshake = 0x16
+
+// handleConnect hijacks the client connection of a CONNECT request. TLS
+// streams are intercepted when a CA is configured; anything else is relayed
+// to the requested authority byte for byte and recorded as a tunnel once
+// either side closes.
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	host := r.URL.Host
+	if host == "" {
+		host = r.Host
+	}
+	target := tunnelTarget{authority: host, user: proxyUser(r)}
+
+	// Without interception the target is dialed before the client is told
+	// the tunnel is up, so dial failures surface as a proper 502.
+	var upstream net.Conn
+	if p.ca == nil {
+		var err error
+		upstream, err = p.dialTunnel(host)
+		if err != nil {
+			http.Error(w, "Failed to reach destination", http.StatusBadGateway)
+			log.Printf("Failed to dial tunnel target %s: %v", host, err)
+			return
+		}
+		defer upstream.Close()
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Tunneling not supported", http.StatusInternalServerError)
+		log.Printf("Response writer for %s does not support hijacking", host)
+		return
+	}
+	client, rw, err := hj.Hijack()
+	if err != nil {
+		log.Printf("Failed to hijack connection for %s: %v", host, err)
+		return
+	}
+	defer client.Close()
+	stopConnTap(r)
+
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		log.Printf("Failed to confirm tunnel to %s: %v", host, err)
+		return
+	}
+
+	// Bytes the client sent right behind the CONNECT line may already sit
+	// in the server's read buffer; they belong to the tunnel.
+	conn := &bufferedConn{Conn: client, r: rw.Reader}
+
+	if p.ca != nil {
+		// Server-first protocols never send anything before the target does,
+		// so the client only gets sniffTimeout to start a TLS handshake.
+		client.SetReadDeadline(time.Now().Add(sniffTimeout))
+		first, err := conn.r.Peek(1)
+		client.SetReadDeadline(time.Time{})
+		var ne net.Error
+		switch {
+		case err == nil && first[0] == recordTypeHandshake:
+			p.serveMITM(conn, target)
+			return
+		case err != nil && !(errors.As(err, &ne) && ne.Timeout()):
+			return
+		}
+		upstream, err = p.dialTunnel(host)
+		if err != nil {
+			log.Printf("Failed to dial tunnel target %s: %v", host, err)
+			return
+		}
+		defer upstream.Close()
+	}
+
+	out, in := pipe(client, conn, upstream)
+	p.recordTunnel(start, r.RemoteAddr, "CONNECT", target, upstream, out, in)
+}
+
+// recordTunnel records a relayed, uninterpreted tunnel once it is done.
+func (p *Proxy) recordTunnel(start time.Time, clientAddr, protocol string, target tunnelTarget, upstream net.Conn, out, in int64) {
+	end := time.Now()
+	p.eng.AddFlow(&apix.Flow{
+		StartedAtMs:    start.UnixMilli(),
+		FinishedAtMs:   end.UnixMilli(),
+		DurationMs:     end.Sub(start).Milliseconds(),
+		ClientAddr:     clientAddr,
+		UpstreamRoute:  p.upstream.routeName(target.authority),
+		ProxyUser:      target.user,
+		NetworkProfile: p.upstream.networkProfile(target.authority),
+		RemoteIp:       upstreamIP(upstream),
+		Tunnel: &apix.Tunnel{
+			Host:       target.authority,
+			BytesIn:    in,
+			BytesOut:   out,
+			DurationMs: end.Sub(start).Milliseconds(),
+			Timestamp:  start.Unix(),
+			Protocol:   protocol,
+		},
+	})
+}
+
+func (p *Proxy) dialTunnel(host string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tunnelDialTimeout)
+	defer cancel()
+	return p.upstream.dial(ctx, host)
+}
+
+// bufferedConn is a hijacked connection whose reads drain the server's
+// buffered reader first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *bufferedConn) CloseWrite() error {
+	closeWrite(c.Conn)
+	return nil
+}
+
+// pipe relays bytes between a hijacked client connection and an upstream
+// connection until both directions are done, returning the number of bytes
+// sent by the client and by the upstream. clientSrc is the reader for the
+// client side, which may replay bytes already buffered by the HTTP server.
+// When one direction reaches EOF the write side of its destination is
+// closed so the peer observes the shutdown.
+func pipe(client net.Conn, clientSrc io.Reader, upstream net.Conn) (out, in int64) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		out, _ = io.Copy(upstream, clientSrc)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		in, _ = io.Copy(client, upstream)
+		closeWrite(client)
+	}()
+	wg.Wait()
+	return out, in
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = c.Close()
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"github.com/mnafshin/apix/pkg/tamper"
+)
+
+func newFaults(cfg config.FaultsConfig) *tamper.Engine {
+	faults := tamper.New()
+	for _, r := range cfg.Rules {
+		probability := 1.0
+		if r.Probability != nil {
+			probability = *r.Probability
+		}
+		err := faults.Add(tamper.Rule{
+			URL:         r.URL,
+			Methods:     r.Methods,
+			Kind:        tamper.Kind(r.Fault),
+			Probability: probability,
+			Status:      r.Status,
+			Body:        r.Body,
+			Duration:    time.Duration(r.DurationMs) * time.Millisecond,
+			TruncateAt:  r.TruncateAt,
+		})
+		if err != nil {
+			log.Printf("Ignoring fault rule for %q: %v", r.URL, err)
+		}
+	}
+	return faults
+}
+
+// injectFault answers r the way fault says instead of forwarding it. The
+// body of an injected response goes to rec. Truncate faults need the
+// upstream response and are not handled here.
+func (p *Proxy) injectFault(w http.ResponseWriter, r *http.Request, fault *tamper.Rule, flow *apix.Flow, rec io.Writer) {
+	switch fault.Kind {
+	case tamper.Status:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		flow.Response = &apix.HttpResponse{
+			StatusCode: int32(fault.Status),
+			HeaderList: headerList(w.Header()),
+		}
+		flow.Response.Headers = headerMap(flow.Response.HeaderList)
+		w.WriteHeader(fault.Status)
+		io.WriteString(io.MultiWriter(w, rec), fault.Body)
+
+	case tamper.Reset:
+		flow.Error = "connection reset by injected fault"
+		abortConn(w)
+
+	case tamper.Hang:
+		var timeout <-chan time.Time
+		if fault.Duration > 0 {
+			t := time.NewTimer(fault.Duration)
+			defer t.Stop()
+			timeout = t.C
+		}
+		select {
+		case <-r.Context().Done():
+			flow.Error = "client gave up during injected hang"
+		case <-timeout:
+			flow.Error = "connection dropped after injected hang"
+			abortConn(w)
+		}
+
+	case tamper.Malformed:
+		flow.Error = "malformed response injected"
+		conn := hijack(w)
+		if conn == nil {
+			// HTTP/2 streams cannot carry raw bytes; resetting the stream
+			// is the closest a handler gets.
+			panic(http.ErrAbortHandler)
+		}
+		io.WriteString(conn, tamper.MalformedResponse)
+		conn.Close()
+	}
+}
+
+// abortConn drops the client connection without a response, with a TCP
+// reset where the connection can be taken over. HTTP/2 requests get their
+// stream reset instead.
+func abortConn(w http.ResponseWriter) {
+	conn := hijack(w)
+	if conn == nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp := tcpConn(conn); tcp != nil {
+		tcp.SetLinger(0)
+		tcp.Close()
+	}
+	conn.Close()
+}
+
+func hijack(w http.ResponseWriter) net.Conn {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return nil
+	}
+	return conn
+}
+
+// tcpConn finds the client TCP connection under the wrappers the proxy
+// puts around it, including the TLS layer of intercepted tunnels.
+func tcpConn(conn net.Conn) *net.TCPConn {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c
+		case *closeNotifyConn:
+			conn = c.Conn
+		case *headerTap:
+			conn = c.Conn
+		case *tlsHeaderTap:
+			conn = c.headerTap.Conn
+		case *tls.Conn:
+			conn = c.NetConn()
+		case *bufferedConn:
+			conn = c.Conn
+		default:
+			return nil
+		}
+	}
+}
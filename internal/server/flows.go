@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mnafshin/apix/internal/filter"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+func (s *EngineServer) ListFlows(ctx context.Context, req *apix.ListFlowsRequest) (*apix.ListFlowsResponse, error) {
+	match, err := filter.Parse(req.Filter)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	return listFlows(s.engine.Flows(match), req.Sort, req.PageSize, req.PageToken)
+}
+
+func (s *EngineServer) SearchFlows(ctx context.Context, req *apix.SearchFlowsRequest) (*apix.ListFlowsResponse, error) {
+	if req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty query")
+	}
+	match, err := filter.Parse(req.Filter)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	query := []byte(strings.ToLower(req.Query))
+	var found []*apix.Flow
+	for _, flow := range s.engine.Flows(match) {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		if containsText(flow, query) {
+			found = append(found, flow)
+		}
+	}
+	return listFlows(found, req.Sort, req.PageSize, req.PageToken)
+}
+
+func (s *EngineServer) GetFlow(ctx context.Context, req *apix.GetFlowRequest) (*apix.Flow, error) {
+	stored, ok := s.engine.Flow(req.Id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no flow %q", req.Id)
+	}
+	flow := proto.Clone(stored).(*apix.Flow)
+	if req := flow.Request; req != nil && req.BodyFile != "" {
+		req.Body = readBodyFile(req.BodyFile)
+	}
+	if resp := flow.Response; resp != nil && resp.BodyFile != "" {
+		resp.Body = readBodyFile(resp.BodyFile)
+	}
+	return flow, nil
+}
+
+func (s *EngineServer) DeleteFlows(ctx context.Context, req *apix.DeleteFlowsRequest) (*apix.DeleteFlowsResponse, error) {
+	if len(req.Ids) == 0 && req.Filter == "" {
+		return nil, status.Error(codes.InvalidArgument, "no flow IDs or filter given")
+	}
+	var match *filter.Filter
+	if req.Filter != "" {
+		var err error
+		if match, err = filter.Parse(req.Filter); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+		}
+	}
+	return &apix.DeleteFlowsResponse{Deleted: int64(s.engine.DeleteFlows(req.Ids, match))}, nil
+}
+
+func (s *EngineServer) ClearFlows(ctx context.Context, req *apix.ClearFlowsRequest) (*apix.DeleteFlowsResponse, error) {
+	return &apix.DeleteFlowsResponse{Deleted: int64(s.engine.ClearFlows())}, nil
+}
+
+// listFlows sorts flows and returns the page of them a request asked for,
+// without bodies. Page tokens are offsets into the sorted flows.
+func listFlows(flows []*apix.Flow, sort string, pageSize int32, pageToken string) (*apix.ListFlowsResponse, error) {
+	if sort == "" {
+		sort = "seq"
+	}
+	order, err := filter.Order(sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	size := int(pageSize)
+	switch {
+	case size < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page size %d", size)
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+	offset := 0
+	if pageToken != "" {
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %q", pageToken)
+		}
+	}
+
+	slices.SortStableFunc(flows, order)
+	resp := &apix.ListFlowsResponse{Total: int64(len(flows))}
+	end := min(offset+size, len(flows))
+	for _, flow := range flows[min(offset, end):end] {
+		resp.Flows = append(resp.Flows, summary(flow))
+	}
+	if end < len(flows) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+// summary copies a flow without its bodies and server-sent events.
+func summary(flow *apix.Flow) *apix.Flow {
+	flow = proto.Clone(flow).(*apix.Flow)
+	if flow.Request != nil {
+		flow.Request.Body = nil
+	}
+	if flow.Response != nil {
+		flow.Response.Body = nil
+	}
+	flow.SseEvents = nil
+	return flow
+}
+
+// containsText reports whether the URL, headers or bodies of a flow
+// contain query, which is lower case.
+func containsText(flow *apix.Flow, query []byte) bool {
+	contains := func(s string) bool {
+		return bytes.Contains([]byte(strings.ToLower(s)), query)
+	}
+	if contains(flow.GetRequest().GetUrl()) || contains(flow.GetTunnel().GetHost()) || contains(flow.RemoteUrl) {
+		return true
+	}
+
+	var headers [][]*apix.Header
+	var bodies [][]byte
+	var files []string
+	if req := flow.Request; req != nil {
+		headers = append(headers, req.HeaderList, req.Trailers)
+		bodies = append(bodies, req.Body)
+		files = append(files, req.BodyFile)
+	}
+	if resp := flow.Response; resp != nil {
+		headers = append(headers, resp.HeaderList, resp.Trailers)
+		bodies = append(bodies, resp.Body)
+		files = append(files, resp.BodyFile)
+	}
+	for _, list := range headers {
+		for _, h := range list {
+			if contains(h.Name + ": " + h.Value) {
+				return true
+			}
+		}
+	}
+	for _, event := range flow.SseEvents {
+		if contains(event.Data) {
+			return true
+		}
+	}
+	for _, body := range bodies {
+		if bytes.Contains(bytes.ToLower(body), query) {
+			return true
+		}
+	}
+	// Spilled bodies are read last, as they may be large.
+	for _, file := range files {
+		if file != "" && bytes.Contains(bytes.ToLower(readBodyFile(file)), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBodyFile reads a body spilled to disk. The file is gone if the flow
+// was evicted meanwhile.
+func readBodyFile(name string) []byte {
+	body, err := os.ReadFile(name)
+	if err != nil {
+		log.Printf("Failed to read captured body: %v", err)
+	}
+	return body
+}
@@ -2,39 +2,133 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
+	"time"
 
 	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"github.com/mnafshin/apix/internal/config"
 	"github.com/mnafshin/apix/internal/engine"
+	"github.com/mnafshin/apix/internal/filter"
+	"github.com/mnafshin/apix/internal/netsim"
+	"github.com/mnafshin/apix/internal/resolver"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type EngineServer struct {
 	apix.UnimplementedEngineServer
-	engine *engine.Engine
+	engine   *engine.Engine
+	network  *netsim.Simulator
+	resolver *resolver.Resolver
 }
 
-func NewEngineServer(eng *engine.Engine) *EngineServer {
-	return &EngineServer{engine: eng}
+func NewEngineServer(eng *engine.Engine, network *netsim.Simulator, dns *resolver.Resolver) *EngineServer {
+	return &EngineServer{engine: eng, network: network, resolver: dns}
 }
 
 func (s *EngineServer) GetStatus(ctx context.Context, req *apix.StatusRequest) (*apix.StatusResponse, error) {
-	return &apix.StatusResponse{Status: "OK", Version: "1.0.0"}, nil
+	return &apix.StatusResponse{Status: "OK", Version: "1.0.0", Retention: s.engine.Retention()}, nil
 }
 
 func (s *EngineServer) CaptureTraffic(req *apix.CaptureRequest, stream apix.Engine_CaptureTrafficServer) error {
-	ch := s.engine.Subscribe()
-	defer s.engine.Unsubscribe(ch)
+	from, err := captureCursor(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	match, err := filter.Parse(req.Filter)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	sub, err := s.engine.Subscribe(config.SubscribersConfig{
+		Policy:         req.Policy,
+		BufferSize:     int(req.BufferSize),
+		BlockTimeoutMs: req.BlockTimeoutMs,
+	}, from, match)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer s.engine.Unsubscribe(sub)
+
+	for {
+		flow, err := sub.Next(stream.Context())
+		if errors.Is(err, engine.ErrSlowSubscriber) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if err != nil {
+			return nil
+		}
+		if err := stream.Send(flow); err != nil {
+			return err
+		}
+	}
+}
+
+// captureCursor selects the stored flows a capture stream starts with.
+func captureCursor(req *apix.CaptureRequest) (engine.Cursor, error) {
+	var from engine.Cursor
+	set := 0
+	if req.FromSeq > 0 {
+		from.FromSeq = req.FromSeq
+		set++
+	}
+	if req.Last < 0 {
+		return from, fmt.Errorf("invalid last %d", req.Last)
+	}
+	if req.Last > 0 {
+		from.Last = int(req.Last)
+		set++
+	}
+	if req.SinceMs > 0 {
+		from.Since = time.UnixMilli(req.SinceMs)
+		set++
+	}
+	if set > 1 {
+		return from, errors.New("at most one of from_seq, last and since_ms may be set")
+	}
+	return from, nil
+}
+
+func (s *EngineServer) CaptureWebSocket(req *apix.WebSocketCaptureRequest, stream apix.Engine_CaptureWebSocketServer) error {
+	ch := s.engine.SubscribeWebSocket()
+	defer s.engine.UnsubscribeWebSocket(ch)
 
 	for {
 		select {
-		case reqInfo, ok := <-ch:
+		case frame, ok := <-ch:
 			if !ok {
 				return nil
 			}
-			if err := stream.Send(reqInfo); err != nil {
+			if req.FlowId != "" && frame.FlowId != req.FlowId {
+				continue
+			}
+			if err := stream.Send(frame); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *EngineServer) CaptureServerSentEvents(req *apix.ServerSentEventCaptureRequest, stream apix.Engine_CaptureServerSentEventsServer) error {
+	ch := s.engine.SubscribeServerSentEvents()
+	defer s.engine.UnsubscribeServerSentEvents(ch)
+
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if req.FlowId != "" && event.FlowId != req.FlowId {
+				continue
+			}
+			if err := stream.Send(event); err != nil {
 				return err
 			}
 		case <-stream.Context().Done():
@@ -52,13 +146,63 @@ func (s *EngineServer) ListPlugins(ctx context.Context, req *apix.PluginListRequ
 	}, nil
 }
 
-func StartGRPCServer(ctx context.Context, eng *engine.Engine, port string) {
+func (s *EngineServer) GetNetworkProfiles(ctx context.Context, req *apix.NetworkProfilesRequest) (*apix.NetworkProfilesResponse, error) {
+	return s.networkProfiles(), nil
+}
+
+func (s *EngineServer) SetNetworkProfile(ctx context.Context, req *apix.SetNetworkProfileRequest) (*apix.NetworkProfilesResponse, error) {
+	if err := s.network.Set(req.Host, req.Profile); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("Network profile for %q set to %q", req.Host, req.Profile)
+	return s.networkProfiles(), nil
+}
+
+func (s *EngineServer) networkProfiles() *apix.NetworkProfilesResponse {
+	resp := &apix.NetworkProfilesResponse{}
+	for _, p := range s.network.Profiles() {
+		resp.Profiles = append(resp.Profiles, &apix.NetworkProfile{
+			Name:             p.Name,
+			DownloadKbps:     p.DownloadKbps,
+			UploadKbps:       p.UploadKbps,
+			LatencyMs:        p.Latency.Milliseconds(),
+			JitterMs:         p.Jitter.Milliseconds(),
+			ResetProbability: p.ResetProbability,
+		})
+	}
+	for _, a := range s.network.Assignments() {
+		resp.Assignments = append(resp.Assignments, &apix.NetworkProfileAssignment{Host: a.Host, Profile: a.Profile})
+	}
+	return resp
+}
+
+func (s *EngineServer) GetHostOverrides(ctx context.Context, req *apix.HostOverridesRequest) (*apix.HostOverridesResponse, error) {
+	return s.hostOverrides(), nil
+}
+
+func (s *EngineServer) SetHostOverride(ctx context.Context, req *apix.HostOverride) (*apix.HostOverridesResponse, error) {
+	if err := s.resolver.SetOverride(req.Host, req.Target); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("Host override for %q set to %q", req.Host, req.Target)
+	return s.hostOverrides(), nil
+}
+
+func (s *EngineServer) hostOverrides() *apix.HostOverridesResponse {
+	resp := &apix.HostOverridesResponse{Resolver: s.resolver.Source()}
+	for _, o := range s.resolver.Overrides() {
+		resp.Overrides = append(resp.Overrides, &apix.HostOverride{Host: o.Host, Target: o.Target})
+	}
+	return resp
+}
+
+func StartGRPCServer(ctx context.Context, eng *engine.Engine, network *netsim.Simulator, dns *resolver.Resolver, port string) {
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
 		log.Fatalf("Failed to listen on :%s: %v", port, err)
 	}
 	grpcServer := grpc.NewServer()
-	apix.RegisterEngineServer(grpcServer, NewEngineServer(eng))
+	apix.RegisterEngineServer(grpcServer, NewEngineServer(eng, network, dns))
 	reflection.Register(grpcServer)
 
 	go func() {
@@ -0,0 +1,343 @@
+package server
+
+import (
+	"encoding/binary"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	frameHeaderLen = 9
+
+	frameHeaders      = 0x1
+	framePushPromise  = 0x5
+	frameContinuation = 0x9
+
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+
+	// maxTapTableSize bounds the HPACK dynamic table a peer may ask for;
+	// real encoders stay far below it.
+	maxTapTableSize = 1 << 20
+
+	// maxTapStreams bounds the streams a tap keeps headers for. Streams
+	// whose requests never reach the proxy handler are never released, so
+	// the oldest are forgotten beyond this.
+	maxTapStreams = 1000
+)
+
+// Direction of a header block relative to the tapped connection.
+const (
+	dirRead = iota
+	dirWrite
+)
+
+// h2Tap wraps an HTTP/2 connection and decodes the header blocks flowing
+// in both directions, so captured flows can report stream IDs and headers
+// in their original order. Decoding errors only disable the tap.
+type h2Tap struct {
+	net.Conn
+	mu      sync.Mutex
+	parsers [2]*frameParser
+	streams map[uint32]*h2Stream
+	opener  int    // direction in which streams are opened
+	opened  uint32 // the stream opened last
+}
+
+// h2Stream collects the decoded header blocks of one stream per direction.
+type h2Stream struct {
+	blocks  [2][][]hpack.HeaderField
+	claimed bool
+}
+
+// newH2Tap taps conn. client reports whether the tapped side is the client
+// of the connection, i.e. writes start with the HTTP/2 connection preface.
+func newH2Tap(conn net.Conn, client bool) *h2Tap {
+	t := &h2Tap{Conn: conn, streams: make(map[uint32]*h2Stream), opener: dirRead}
+	if client {
+		t.opener = dirWrite
+	}
+	for dir := range t.parsers {
+		fp := newFrameParser(func(stream uint32, fields []hpack.HeaderField) {
+			t.addBlock(dir, stream, fields)
+		})
+		if (dir == dirWrite) == client {
+			fp.skip = len(http2.ClientPreface)
+		}
+		t.parsers[dir] = fp
+	}
+	return t
+}
+
+func (t *h2Tap) Read(b []byte) (int, error) {
+	n, err := t.Conn.Read(b)
+	if n > 0 {
+		t.mu.Lock()
+		t.parsers[dirRead].feed(b[:n])
+		t.mu.Unlock()
+	}
+	return n, err
+}
+
+func (t *h2Tap) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	t.parsers[dirWrite].feed(b)
+	t.mu.Unlock()
+	return t.Conn.Write(b)
+}
+
+// addBlock is called with t.mu held. Blocks of streams that were already
+// released, such as late trailers, are dropped.
+func (t *h2Tap) addBlock(dir int, stream uint32, fields []hpack.HeaderField) {
+	s, ok := t.streams[stream]
+	if !ok {
+		if dir != t.opener || stream <= t.opened {
+			return
+		}
+		t.opened = stream
+		if len(t.streams) == maxTapStreams {
+			delete(t.streams, slices.Min(slices.Collect(maps.Keys(t.streams))))
+		}
+		s = &h2Stream{}
+		t.streams[stream] = s
+	}
+	s.blocks[dir] = append(s.blocks[dir], fields)
+}
+
+// claim finds the lowest unclaimed stream whose first header block in
+// direction dir carries the given method, path and authority and the
+// header fields of h. Streams that match the same request in every field
+// cannot be told apart, and are handed out in order.
+func (t *h2Tap) claim(dir int, method, path, authority string, h http.Header) (uint32, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var found uint32
+	for id, s := range t.streams {
+		if s.claimed || len(s.blocks[dir]) == 0 || (found != 0 && id > found) {
+			continue
+		}
+		first := s.blocks[dir][0]
+		if pseudo(first, ":method") == method && pseudo(first, ":path") == path &&
+			(authority == "" || pseudo(first, ":authority") == authority) && sameFields(first, h) {
+			found = id
+		}
+	}
+	if found == 0 {
+		return 0, false
+	}
+	t.streams[found].claimed = true
+	return found, true
+}
+
+// claimOpened claims the stream opened last. Called while the writer of
+// the header block that opened a stream still holds the connection's write
+// lock, it returns that stream.
+func (t *h2Tap) claimOpened() (uint32, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.streams[t.opened]
+	if !ok || s.claimed {
+		return 0, false
+	}
+	s.claimed = true
+	return t.opened, true
+}
+
+// headers returns the main header block of stream in direction dir,
+// skipping interim 1xx responses, and any trailer block that followed it.
+func (t *h2Tap) headers(dir int, stream uint32) (headers, trailers []*apix.Header) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.streams[stream]
+	if !ok {
+		return nil, nil
+	}
+	main := -1
+	for i, block := range s.blocks[dir] {
+		if strings.HasPrefix(pseudo(block, ":status"), "1") {
+			continue
+		}
+		if main < 0 {
+			main = i
+			headers = fieldHeaders(block)
+			continue
+		}
+		trailers = append(trailers, fieldHeaders(block)...)
+	}
+	return headers, trailers
+}
+
+// release forgets a stream once its flow has been recorded.
+func (t *h2Tap) release(stream uint32) {
+	t.mu.Lock()
+	delete(t.streams, stream)
+	t.mu.Unlock()
+}
+
+// sameFields reports whether the regular header fields of a block are
+// those of h. Cookie fields are skipped, as servers join them into one.
+func sameFields(fields []hpack.HeaderField, h http.Header) bool {
+	seen := make(map[string]int)
+	for _, f := range fields {
+		if f.IsPseudo() || f.Name == "cookie" {
+			continue
+		}
+		name := http.CanonicalHeaderKey(f.Name)
+		values := h[name]
+		if seen[name] >= len(values) || values[seen[name]] != f.Value {
+			return false
+		}
+		seen[name]++
+	}
+	for name, values := range h {
+		if name != "Cookie" && seen[name] != len(values) {
+			return false
+		}
+	}
+	return true
+}
+
+func pseudo(fields []hpack.HeaderField, name string) string {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// fieldHeaders converts decoded fields to headers, dropping pseudo-headers
+// which the flow already carries as method, URL and status.
+func fieldHeaders(fields []hpack.HeaderField) []*apix.Header {
+	headers := make([]*apix.Header, 0, len(fields))
+	for _, f := range fields {
+		if f.IsPseudo() {
+			continue
+		}
+		headers = append(headers, &apix.Header{Name: f.Name, Value: f.Value})
+	}
+	return headers
+}
+
+// frameParser incrementally splits one direction of an HTTP/2 byte stream
+// into frames and decodes header blocks. Frame payloads other than header
+// blocks are skipped without being buffered.
+type frameParser struct {
+	dec     *hpack.Decoder
+	onBlock func(stream uint32, fields []hpack.HeaderField)
+	broken  bool
+
+	skip   int    // bytes to discard before the next frame header
+	header []byte // partial frame header
+	need   int    // payload bytes still missing from payload
+	frame  [frameHeaderLen]byte
+
+	payload     []byte
+	fragment    []byte // header block assembled across CONTINUATION frames
+	blockStream uint32
+}
+
+func newFrameParser(onBlock func(uint32, []hpack.HeaderField)) *frameParser {
+	dec := hpack.NewDecoder(4096, nil)
+	dec.SetAllowedMaxDynamicTableSize(maxTapTableSize)
+	return &frameParser{dec: dec, onBlock: onBlock}
+}
+
+func (p *frameParser) feed(b []byte) {
+	for len(b) > 0 && !p.broken {
+		switch {
+		case p.skip > 0:
+			n := min(p.skip, len(b))
+			p.skip -= n
+			b = b[n:]
+
+		case p.need > 0:
+			n := min(p.need, len(b))
+			p.payload = append(p.payload, b[:n]...)
+			p.need -= n
+			b = b[n:]
+			if p.need == 0 {
+				p.handleFrame()
+			}
+
+		default:
+			n := min(frameHeaderLen-len(p.header), len(b))
+			p.header = append(p.header, b[:n]...)
+			b = b[n:]
+			if len(p.header) < frameHeaderLen {
+				continue
+			}
+			copy(p.frame[:], p.header)
+			p.header = p.header[:0]
+
+			length := int(p.frame[0])<<16 | int(p.frame[1])<<8 | int(p.frame[2])
+			switch p.frame[3] {
+			case frameHeaders, framePushPromise, frameContinuation:
+				p.payload = p.payload[:0]
+				p.need = length
+				if length == 0 {
+					p.handleFrame()
+				}
+			default:
+				p.skip = length
+			}
+		}
+	}
+}
+
+func (p *frameParser) handleFrame() {
+	typ, flags := p.frame[3], p.frame[4]
+	stream := binary.BigEndian.Uint32(p.frame[5:]) & (1<<31 - 1)
+	payload := p.payload
+
+	if typ != frameContinuation {
+		if flags&flagPadded != 0 {
+			if len(payload) < 1 || int(payload[0]) >= len(payload) {
+				p.broken = true
+				return
+			}
+			payload = payload[1 : len(payload)-int(payload[0])]
+		}
+		switch {
+		case typ == frameHeaders && flags&flagPriority != 0:
+			if len(payload) < 5 {
+				p.broken = true
+				return
+			}
+			payload = payload[5:]
+		case typ == framePushPromise:
+			if len(payload) < 4 {
+				p.broken = true
+				return
+			}
+			// Promised requests belong to the promised stream.
+			stream = binary.BigEndian.Uint32(payload) & (1<<31 - 1)
+			payload = payload[4:]
+		}
+		p.fragment = p.fragment[:0]
+		p.blockStream = stream
+	}
+
+	p.fragment = append(p.fragment, payload...)
+	if flags&flagEndHeaders == 0 {
+		return
+	}
+	fields, err := p.dec.DecodeFull(p.fragment)
+	if err != nil {
+		p.broken = true
+		return
+	}
+	p.onBlock(p.blockStream, fields)
+}
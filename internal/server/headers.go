@@ -0,0 +1,326 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// maxTapBytes bounds how much recently read data a headerTap keeps. Header
+// blocks larger than this fall back to the parsed, canonicalized headers.
+const maxTapBytes = 64 << 10
+
+// headerTap wraps an HTTP/1.x connection and remembers the most recent
+// bytes read from it, so the raw header block of each message can be
+// recovered in its original order and casing after net/http parsed it.
+type headerTap struct {
+	net.Conn
+	mu      sync.Mutex
+	buf     []byte
+	stopped bool
+}
+
+func newHeaderTap(conn net.Conn) *headerTap {
+	return &headerTap{Conn: conn}
+}
+
+func (t *headerTap) Read(b []byte) (int, error) {
+	n, err := t.Conn.Read(b)
+	if n > 0 {
+		t.mu.Lock()
+		if !t.stopped {
+			t.buf = append(t.buf, b[:n]...)
+			if over := len(t.buf) - maxTapBytes; over > 0 {
+				t.buf = t.buf[:copy(t.buf, t.buf[over:])]
+			}
+		}
+		t.mu.Unlock()
+	}
+	return n, err
+}
+
+func (t *headerTap) CloseWrite() error {
+	if cw, ok := t.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return t.Conn.Close()
+}
+
+// stop ends recording, e.g. once the connection carries a tunnel.
+func (t *headerTap) stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.buf = nil
+	t.mu.Unlock()
+}
+
+// take returns the headers of the first recorded header block whose start
+// line satisfies match and forgets everything read up to the end of that
+// block. It returns nil if no such block was recorded.
+func (t *headerTap) take(match func(startLine string) bool) []*apix.Header {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for pos := 0; pos < len(t.buf); {
+		lineEnd := bytes.IndexByte(t.buf[pos:], '\n')
+		if lineEnd < 0 {
+			return nil
+		}
+		line := strings.TrimRight(string(t.buf[pos:pos+lineEnd]), "\r")
+		if !match(line) {
+			pos += lineEnd + 1
+			continue
+		}
+		blockEnd := headerBlockEnd(t.buf[pos:])
+		if blockEnd < 0 {
+			return nil
+		}
+		headers := parseHeaderBlock(t.buf[pos+lineEnd+1 : pos+blockEnd])
+		t.buf = t.buf[:copy(t.buf, t.buf[pos+blockEnd:])]
+		return headers
+	}
+	return nil
+}
+
+// tlsHeaderTap is a headerTap over a client TLS connection. It exposes the
+// connection state so http.Transport still recognizes it as TLS.
+type tlsHeaderTap struct {
+	*headerTap
+	tlsConn *tls.Conn
+}
+
+func (t *tlsHeaderTap) ConnectionState() tls.ConnectionState {
+	return t.tlsConn.ConnectionState()
+}
+
+func (t *tlsHeaderTap) HandshakeContext(ctx context.Context) error {
+	return t.tlsConn.HandshakeContext(ctx)
+}
+
+// tapListener wraps every accepted connection in a headerTap.
+type tapListener struct {
+	net.Listener
+}
+
+func (l tapListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newHeaderTap(conn), nil
+}
+
+// connTapKey carries the tap (*headerTap or *h2Tap) of the client
+// connection a request arrived on.
+type connTapKey struct{}
+
+// withConnTap is an http.Server ConnContext hook that makes the tap of each
+// accepted connection available to its requests.
+func withConnTap(ctx context.Context, conn net.Conn) context.Context {
+	switch conn.(type) {
+	case *headerTap, *h2Tap:
+		return context.WithValue(ctx, connTapKey{}, conn)
+	}
+	return ctx
+}
+
+// stopConnTap stops header recording on the client connection of r once
+// it is hijacked for a tunnel or an upgraded protocol.
+func stopConnTap(r *http.Request) {
+	if tap, ok := r.Context().Value(connTapKey{}).(*headerTap); ok {
+		tap.stop()
+	}
+}
+
+// requestHeaders returns the headers of r as they arrived on the client
+// connection, with proxy credentials redacted. For HTTP/2 it also returns
+// the tap and the stream that carried r; the caller releases the stream
+// once the flow is recorded.
+func requestHeaders(r *http.Request) ([]*apix.Header, *h2Tap, uint32) {
+	switch tap := r.Context().Value(connTapKey{}).(type) {
+	case *h2Tap:
+		if stream, ok := tap.claim(dirRead, r.Method, r.RequestURI, r.Host, r.Header); ok {
+			if headers, _ := tap.headers(dirRead, stream); headers != nil {
+				return redactCredentials(headers), tap, stream
+			}
+			return redactCredentials(headerList(r.Header)), tap, stream
+		}
+	case *headerTap:
+		return redactCredentials(rawHeaders(tap, requestLineMatcher(r), r.Header)), nil, 0
+	}
+	return redactCredentials(headerList(r.Header)), nil, 0
+}
+
+// responseHeaders returns the headers of resp as the upstream sent them,
+// with proxy credentials redacted.
+func responseHeaders(resp *http.Response, res *upstreamResult) []*apix.Header {
+	if res.h2 != nil && res.stream != 0 {
+		if headers, _ := res.h2.headers(dirRead, res.stream); headers != nil {
+			return redactCredentials(headers)
+		}
+		return redactCredentials(headerList(resp.Header))
+	}
+	return redactCredentials(rawHeaders(asHeaderTap(res.conn), statusLineMatcher(resp.StatusCode), resp.Header))
+}
+
+// credentialHeaders carry proxy credentials, which are kept out of captured
+// flows as anyone with access to the API can read those.
+var credentialHeaders = []string{"Proxy-Authorization", "Proxy-Authenticate"}
+
+// redactCredentials replaces the values of credentialHeaders in place,
+// keeping only the authentication scheme, e.g. "Basic [redacted]".
+func redactCredentials(headers []*apix.Header) []*apix.Header {
+	for _, h := range headers {
+		for _, name := range credentialHeaders {
+			if strings.EqualFold(h.Name, name) {
+				scheme, _, _ := strings.Cut(h.Value, " ")
+				h.Value = strings.TrimSpace(scheme + " [redacted]")
+			}
+		}
+	}
+	return headers
+}
+
+// trailers returns the trailers of a finished stream, preferring the order
+// recorded by tap over the parsed trailer map.
+func trailers(tap *h2Tap, dir int, stream uint32, parsed http.Header) []*apix.Header {
+	if tap != nil && stream != 0 {
+		if _, recorded := tap.headers(dir, stream); recorded != nil {
+			return recorded
+		}
+	}
+	var present http.Header
+	for name, vv := range parsed {
+		if len(vv) > 0 {
+			if present == nil {
+				present = make(http.Header)
+			}
+			present[name] = vv
+		}
+	}
+	if present == nil {
+		return nil
+	}
+	return headerList(present)
+}
+
+// asHeaderTap returns the headerTap behind an upstream connection, if any.
+func asHeaderTap(conn net.Conn) *headerTap {
+	switch c := conn.(type) {
+	case *headerTap:
+		return c
+	case *tlsHeaderTap:
+		return c.headerTap
+	}
+	return nil
+}
+
+// rawHeaders returns the headers of the message whose start line satisfies
+// match as recorded by tap, falling back to the parsed header map.
+func rawHeaders(tap *headerTap, match func(string) bool, parsed http.Header) []*apix.Header {
+	if tap != nil {
+		if headers := tap.take(match); headers != nil {
+			return headers
+		}
+	}
+	return headerList(parsed)
+}
+
+// headerBlockEnd returns the offset just past the blank line that ends the
+// header block at the start of b, or -1 if it is incomplete.
+func headerBlockEnd(b []byte) int {
+	for pos := 0; pos < len(b); {
+		i := bytes.IndexByte(b[pos:], '\n')
+		if i < 0 {
+			return -1
+		}
+		next := pos + i + 1
+		if pos > 0 && len(bytes.TrimRight(b[pos:next], "\r\n")) == 0 {
+			return next
+		}
+		pos = next
+	}
+	return -1
+}
+
+// parseHeaderBlock splits raw header lines into name/value pairs, joining
+// obsolete folded continuation lines onto the previous value.
+func parseHeaderBlock(block []byte) []*apix.Header {
+	var headers []*apix.Header
+	for _, line := range strings.Split(string(block), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			last := headers[len(headers)-1]
+			last.Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers = append(headers, &apix.Header{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return headers
+}
+
+// requestLineMatcher matches the request line of r.
+func requestLineMatcher(r *http.Request) func(string) bool {
+	prefix := r.Method + " " + r.RequestURI + " "
+	return func(line string) bool {
+		return strings.HasPrefix(line, prefix)
+	}
+}
+
+// statusLineMatcher matches a final (non-1xx) status line with the given
+// code.
+func statusLineMatcher(code int) func(string) bool {
+	return func(line string) bool {
+		proto, rest, ok := strings.Cut(line, " ")
+		if !ok || !strings.HasPrefix(proto, "HTTP/1.") {
+			return false
+		}
+		status, _, _ := strings.Cut(rest, " ")
+		return status == strconv.Itoa(code)
+	}
+}
+
+// headerList flattens h into name/value pairs sorted by name. It is used
+// when the raw header block is not available, e.g. for HTTP/2.
+func headerList(h http.Header) []*apix.Header {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers []*apix.Header
+	for _, name := range names {
+		for _, v := range h[name] {
+			headers = append(headers, &apix.Header{Name: name, Value: v})
+		}
+	}
+	return headers
+}
+
+// headerMap keeps the first value of each header, keyed by canonical name.
+func headerMap(headers []*apix.Header) map[string]string {
+	m := make(map[string]string, len(headers))
+	for _, hdr := range headers {
+		name := http.CanonicalHeaderKey(hdr.Name)
+		if _, ok := m[name]; !ok {
+			m[name] = hdr.Value
+		}
+	}
+	return m
+}
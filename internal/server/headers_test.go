@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+func TestRedactCredentials(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"Proxy-Authorization", "Basic YWxpY2U6c2VjcmV0", "Basic [redacted]"},
+		{"proxy-authorization", "Bearer abc.def", "Bearer [redacted]"},
+		{"Proxy-Authenticate", `Basic realm="APiX"`, "Basic [redacted]"},
+		{"Proxy-Authorization", "", "[redacted]"},
+		{"Authorization", "Basic YWxpY2U6c2VjcmV0", "Basic YWxpY2U6c2VjcmV0"},
+		{"X-Proxy-Authorization", "token", "token"},
+	}
+	for _, tt := range tests {
+		headers := redactCredentials([]*apix.Header{{Name: tt.name, Value: tt.value}})
+		if got := headers[0].Value; got != tt.want {
+			t.Errorf("%s: %q redacted to %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRequestHeadersRedactProxyAuthorization(t *testing.T) {
+	newRequest := func() *http.Request {
+		r, err := http.NewRequest("GET", "http://example.com/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RequestURI = "http://example.com/"
+		r.Header.Set("Proxy-Authorization", "Basic YWxpY2U6c2VjcmV0")
+		r.Header.Set("Accept", "*/*")
+		return r
+	}
+
+	// Parsed headers, when the client connection is not tapped.
+	r := newRequest()
+	headers, _, _ := requestHeaders(r)
+	if got := headerMap(headers)["Proxy-Authorization"]; got != "Basic [redacted]" {
+		t.Errorf("parsed Proxy-Authorization captured as %q", got)
+	}
+
+	// Raw headers recorded on the client connection.
+	tap := &headerTap{buf: []byte("GET http://example.com/ HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"proxy-authorization: Basic YWxpY2U6c2VjcmV0\r\n" +
+		"Accept: */*\r\n\r\n")}
+	r = newRequest()
+	r = r.WithContext(context.WithValue(r.Context(), connTapKey{}, tap))
+	headers, _, _ = requestHeaders(r)
+	if len(headers) != 3 {
+		t.Fatalf("got %d raw headers, want 3", len(headers))
+	}
+	if h := headers[1]; h.Name != "proxy-authorization" || h.Value != "Basic [redacted]" {
+		t.Errorf("raw header captured as %s: %s", h.Name, h.Value)
+	}
+	if h := headers[2]; h.Value != "*/*" {
+		t.Errorf("Accept captured as %q", h.Value)
+	}
+}
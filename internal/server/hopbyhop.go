@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mnafshin/apix/internal/config"
+	"golang.org/x/net/http/httpguts"
+)
+
+// viaPseudonym identifies APiX in Via headers.
+const viaPseudonym = "apix"
+
+// hopHeaders only apply to a single connection and are not forwarded
+// (RFC 7230, section 6.1). Proxy-Connection is not standard but still sent
+// by some clients.
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopByHop deletes hop-by-hop headers from h, including those named
+// in Connection. An accepted protocol upgrade keeps its Upgrade header and
+// the Connection option announcing it, as the upgrade is negotiated end to
+// end. "TE: trailers" is kept, as gRPC and other upstreams that send
+// trailers require it; other TE values are dropped.
+func removeHopByHop(h http.Header, upgrade bool) {
+	upgradeTo := h.Values("Upgrade")
+	trailers := httpguts.HeaderValuesContainsToken(h["Te"], "trailers")
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+	if trailers {
+		h.Set("Te", "trailers")
+	}
+	if upgrade && len(upgradeTo) > 0 {
+		h.Set("Connection", "Upgrade")
+		h["Upgrade"] = upgradeTo
+	}
+}
+
+// addForwardingHeaders records the hop through APiX on a request about to
+// be sent upstream, as far as cfg asks for it. r is the request received
+// from the client.
+func addForwardingHeaders(cfg config.ForwardingConfig, req, r *http.Request) {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	if cfg.Via {
+		req.Header.Add("Via", viaValue(r.ProtoMajor, r.ProtoMinor))
+	}
+	if cfg.XForwardedFor && clientIP != "" {
+		chain := clientIP
+		if prior := req.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+			chain = strings.Join(prior, ", ") + ", " + clientIP
+		}
+		req.Header.Set("X-Forwarded-For", chain)
+	}
+	if cfg.Forwarded {
+		req.Header.Add("Forwarded", forwardedElement(r, clientIP))
+	}
+}
+
+// viaValue formats this hop for a Via header, e.g. "1.1 apix".
+func viaValue(major, minor int) string {
+	version := strconv.Itoa(major)
+	if major < 2 {
+		version += "." + strconv.Itoa(minor)
+	}
+	return version + " " + viaPseudonym
+}
+
+// forwardedElement describes the client side of r as an RFC 7239
+// forwarded-element.
+func forwardedElement(r *http.Request, clientIP string) string {
+	proto := "http"
+	if t, ok := r.Context().Value(tunnelKey{}).(tunnelTarget); ok && t.tls {
+		proto = "https"
+	}
+
+	var elem []string
+	if clientIP != "" {
+		node := clientIP
+		if strings.Contains(node, ":") {
+			node = `"[` + node + `]"`
+		}
+		elem = append(elem, "for="+node)
+	}
+	if r.Host != "" {
+		elem = append(elem, "host="+quoteForwarded(r.Host))
+	}
+	elem = append(elem, "proto="+proto)
+	return strings.Join(elem, ";")
+}
+
+// quoteForwarded quotes v unless it is a valid token.
+func quoteForwarded(v string) string {
+	for _, c := range v {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
@@ -4,79 +4,341 @@ import (
 	"context"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
-	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"github.com/mnafshin/apix/internal/config"
 	"github.com/mnafshin/apix/internal/engine"
+	"github.com/mnafshin/apix/internal/mitm"
+	"github.com/mnafshin/apix/internal/netsim"
+	"github.com/mnafshin/apix/internal/resolver"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"github.com/mnafshin/apix/pkg/tamper"
 )
 
-func StartHTTPProxy(ctx context.Context, eng *engine.Engine, port string) {
-	transport := &http.Transport{}
+// Proxy is the interception pipeline shared by the proxy listeners. Plain
+// requests, CONNECT tunnels and requests decrypted from intercepted tunnels
+// all end up in ServeHTTP.
+type Proxy struct {
+	eng        *engine.Engine
+	upstream   *upstream
+	http2      bool
+	ca         *mitm.CA
+	capture    config.CaptureConfig
+	forwarding config.ForwardingConfig
+	faults     *tamper.Engine
+	localMap   *tamper.LocalMap
+	remoteMap  *tamper.RemoteMap
+}
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("HTTP proxy received request: %s %s", r.Method, r.URL)
+// NewProxy sets up the pipeline, loading or creating the MITM CA when
+// interception is enabled. Upstream hosts are resolved with dns and their
+// connections are subject to network.
+func NewProxy(eng *engine.Engine, cfg *config.Config, network *netsim.Simulator, dns *resolver.Resolver) *Proxy {
+	p := &Proxy{
+		eng:        eng,
+		upstream:   newUpstream(cfg, network, dns),
+		http2:      cfg.HTTP2.Enabled,
+		capture:    cfg.Capture,
+		forwarding: cfg.Forwarding,
+		faults:     newFaults(cfg.Faults),
+		localMap:   newLocalMap(cfg.MapLocal),
+		remoteMap:  newRemoteMap(cfg.MapRemote),
+	}
 
-		targetURL := r.URL
-		if !targetURL.IsAbs() {
-			scheme := "http"
-			if r.TLS != nil {
-				scheme = "https"
-			}
-			targetURL = &url.URL{
-				Scheme:   scheme,
-				Host:     r.Host,
-				Path:     r.URL.Path,
-				RawQuery: r.URL.RawQuery,
-			}
+	if cfg.MITM.Enabled {
+		certFile, keyFile := cfg.MITM.CACert, cfg.MITM.CAKey
+		if certFile == "" || keyFile == "" {
+			certFile, keyFile = mitm.DefaultPaths()
 		}
-
-		req, err := http.NewRequest(r.Method, targetURL.String(), r.Body)
+		ca, err := mitm.LoadOrCreateCA(certFile, keyFile, cfg.MITM.CertCacheSize)
 		if err != nil {
-			http.Error(w, "Failed to create request", http.StatusInternalServerError)
-			log.Printf("Failed to create request: %v", err)
-			return
+			log.Printf("Failed to load MITM CA, HTTPS will be tunneled without interception: %v", err)
+		} else {
+			log.Printf("MITM enabled, trust the root CA at %s to inspect HTTPS traffic", certFile)
+			p.ca = ca
 		}
+	}
+	return p
+}
 
-		req.Header = r.Header.Clone()
-		resp, err := transport.RoundTrip(req)
+// StartHTTPProxy serves p on the HTTP proxy port and on every configured
+// reverse proxy listener until ctx is done.
+func StartHTTPProxy(ctx context.Context, p *Proxy, cfg *config.Config) {
+	var wg sync.WaitGroup
+	for _, lc := range cfg.Reverse.Listeners {
+		rp, err := newReverseProxy(p, lc)
 		if err != nil {
-			http.Error(w, "Failed to reach destination", http.StatusBadGateway)
-			log.Printf("Failed to reach destination %s: %v", targetURL.String(), err)
-			return
+			log.Printf("Invalid reverse proxy listener on :%s: %v", lc.Port, err)
+			continue
 		}
-		defer resp.Body.Close()
+		srv := &http.Server{Addr: ":" + lc.Port, Handler: rp, ConnContext: withConnTap}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveHTTP(ctx, srv, "reverse proxy")
+		}()
+	}
+
+	// CONNECT requests carry an authority instead of a path, so the proxy
+	// handler is installed directly rather than through a ServeMux.
+	var handler http.Handler = p
+	if auth := newProxyAuth(cfg.ProxyAuth, p); auth != nil {
+		handler = auth
+	}
+	srv := &http.Server{Addr: ":" + cfg.HTTPPort, Handler: handler, ConnContext: withConnTap}
+	serveHTTP(ctx, srv, "HTTP proxy")
+	wg.Wait()
+}
+
+// serveHTTP runs srv on a tapped listener until ctx is done.
+func serveHTTP(ctx context.Context, srv *http.Server, name string) {
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
 
-		for k, vv := range resp.Header {
-			for _, v := range vv {
-				w.Header().Add(k, v)
+	lis, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Printf("Failed to listen on %s: %v", srv.Addr, err)
+		return
+	}
+
+	log.Printf("Starting %s server on %s", name, srv.Addr)
+	if err := srv.Serve(tapListener{lis}); err != http.ErrServerClosed {
+		log.Printf("%s server error: %v", name, err)
+	}
+	log.Printf("%s server stopped", name)
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("HTTP proxy received request: %s %s", r.Method, r.URL)
+
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+
+	targetURL := r.URL
+	if !targetURL.IsAbs() {
+		scheme := "http"
+		host := r.Host
+		if t, ok := r.Context().Value(tunnelKey{}).(tunnelTarget); ok {
+			host = t.authority
+			if t.tls {
+				scheme = "https"
 			}
 		}
-		w.WriteHeader(resp.StatusCode)
-		_, _ = io.Copy(w, resp.Body)
-
-		reqInfo := &apix.HttpRequest{
-			Method:  r.Method,
-			Url:     targetURL.String(),
-			Headers: map[string]string{},
+		targetURL = &url.URL{
+			Scheme:   scheme,
+			Host:     stripDefaultPort(host, scheme),
+			Path:     r.URL.Path,
+			RawQuery: r.URL.RawQuery,
 		}
-		for k, vv := range r.Header {
-			if len(vv) > 0 {
-				reqInfo.Headers[k] = vv[0]
+	}
+
+	start := time.Now()
+	var clientTap *h2Tap
+	var clientStream uint32
+	flow := &apix.Flow{
+		StartedAtMs: start.UnixMilli(),
+		ClientAddr:  r.RemoteAddr,
+		ProxyUser:   proxyUser(r),
+		Request: &apix.HttpRequest{
+			Method:    r.Method,
+			Url:       targetURL.String(),
+			Timestamp: start.Unix(),
+		},
+	}
+	flow.Request.HeaderList, clientTap, clientStream = requestHeaders(r)
+	flow.Request.Headers = headerMap(flow.Request.HeaderList)
+	flow.ClientProtocol = r.Proto
+	flow.ClientStreamId = clientStream
+	reqBody := newBodyRecorder(p.capture)
+	respBody := newBodyRecorder(p.capture)
+	var resp *http.Response
+	var res *upstreamResult
+	var timer *phaseTimer
+	var recordOnce sync.Once
+	record := func() {
+		recordOnce.Do(func() {
+			flow.Request.Trailers = trailers(clientTap, dirRead, clientStream, r.Trailer)
+			if clientTap != nil {
+				clientTap.release(clientStream)
+			}
+			if resp != nil {
+				flow.Response.Trailers = trailers(res.h2, dirRead, res.stream, resp.Trailer)
+				if res.h2 != nil {
+					res.h2.release(res.stream)
+				}
+			}
+
+			captured := reqBody.finish()
+			flow.Request.Body = captured.data
+			flow.Request.BodyFile = captured.file
+			flow.Request.BodySize = captured.size
+			flow.Request.BodyTruncated = captured.truncated
+			if flow.Response != nil {
+				captured := respBody.finish()
+				flow.Response.Body = captured.data
+				flow.Response.BodyFile = captured.file
+				flow.Response.BodySize = captured.size
+				flow.Response.BodyTruncated = captured.truncated
+			}
+
+			end := time.Now()
+			flow.FinishedAtMs = end.UnixMilli()
+			flow.DurationMs = end.Sub(start).Milliseconds()
+			if timer != nil {
+				flow.Timing = timer.timing(end)
+			}
+			if flow.Open {
+				flow.Open = false
+				p.eng.UpdateFlow(flow)
+			} else {
+				p.eng.AddFlow(flow)
 			}
+		})
+	}
+	defer record()
+
+	req, err := http.NewRequest(r.Method, targetURL.String(), newTeeBody(r.Body, reqBody))
+	if err != nil {
+		http.Error(w, "Failed to create request", http.StatusInternalServerError)
+		log.Printf("Failed to create request: %v", err)
+		flow.Error = err.Error()
+		return
+	}
+
+	// The capture keeps the headers as the client sent them; only the
+	// forwarded copy loses its hop-by-hop headers.
+	req.Header = r.Header.Clone()
+	req.ContentLength = r.ContentLength
+	req.Trailer = r.Trailer
+	upgrade := isWebSocketUpgrade(r.Header)
+	removeHopByHop(req.Header, upgrade)
+	addForwardingHeaders(p.forwarding, req, r)
+	if upgrade {
+		// Without compression negotiated, recorded frames stay readable.
+		req.Header.Del("Sec-WebSocket-Extensions")
+	}
+
+	if rule, file := p.localMap.Resolve(targetURL.String()); rule != nil {
+		log.Printf("Serving %s %s from %s", r.Method, targetURL, file)
+		// The request body is still read so it is captured.
+		io.Copy(io.Discard, req.Body)
+		p.serveLocal(w, r, targetURL, rule, file, flow, respBody)
+		return
+	}
+
+	if rule, mapped := p.remoteMap.Rewrite(targetURL.String()); rule != nil {
+		u, err := url.Parse(mapped)
+		if err != nil || u.Host == "" {
+			http.Error(w, "Invalid map remote target", http.StatusBadGateway)
+			log.Printf("Invalid map remote target %q for %s", mapped, targetURL)
+			flow.Error = "invalid map remote target " + strconv.Quote(mapped)
+			return
 		}
-		eng.AddRequest(reqInfo)
-	})
+		log.Printf("Mapping %s to %s", targetURL, u)
+		req.URL = u
+		if !rule.PreserveHost {
+			req.Host = u.Host
+		}
+		flow.RemoteUrl = u.String()
+	}
 
-	srv := &http.Server{Addr: ":" + port}
-	go func() {
-		<-ctx.Done()
-		srv.Shutdown(context.Background())
-	}()
+	authority := canonicalAuthority(req)
+	flow.UpstreamRoute = p.upstream.routeName(authority)
+	flow.NetworkProfile = p.upstream.networkProfile(authority)
+	fault := p.faults.Match(r.Method, targetURL.String())
+	if fault != nil {
+		log.Printf("Injecting fault into %s %s: %s", r.Method, targetURL, fault)
+		flow.Fault = &apix.Fault{Kind: string(fault.Kind), Rule: fault.URL, Detail: fault.String()}
+		if fault.Kind != tamper.Truncate {
+			p.injectFault(w, r, fault, flow, respBody)
+			return
+		}
+	}
+	timer = newPhaseTimer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+	resp, res, err = p.upstream.roundTrip(req)
+	if err != nil {
+		http.Error(w, "Failed to reach destination", http.StatusBadGateway)
+		log.Printf("Failed to reach destination %s: %v", targetURL.String(), err)
+		flow.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	flow.UpstreamProtocol = resp.Proto
+	if res.h2 != nil {
+		flow.UpstreamStreamId = res.stream
+		flow.RemoteIp = upstreamIP(res.h2)
+	} else {
+		flow.RemoteIp = upstreamIP(res.conn)
+	}
+	flow.UpstreamTls = upstreamTLS(resp, res)
+	flow.Response = &apix.HttpResponse{
+		StatusCode: int32(resp.StatusCode),
+		HeaderList: responseHeaders(resp, res),
+	}
+	flow.Response.Headers = headerMap(flow.Response.HeaderList)
+
+	if resp.StatusCode == http.StatusSwitchingProtocols && isWebSocketUpgrade(resp.Header) {
+		// The handshake is recorded right away so frames can refer to it.
+		record()
+		p.relayWebSocket(w, r, resp, flow.Id)
+		return
+	}
+
+	header := resp.Header.Clone()
+	removeHopByHop(header, false)
+	for k, vv := range header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	for k := range resp.Trailer {
+		w.Header().Add("Trailer", k)
+	}
+	if p.forwarding.Via {
+		w.Header().Add("Via", viaValue(resp.ProtoMajor, resp.ProtoMinor))
+	}
+	if t, ok := r.Context().Value(reverseRouteKey{}).(*reverseTarget); ok {
+		t.rewriteLocation(w.Header())
+	}
+	w.WriteHeader(resp.StatusCode)
 
-	log.Printf("Starting HTTP proxy server on :%s", port)
-	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-		log.Printf("HTTP server error: %v", err)
+	var body io.Writer = respBody
+	if isStreamingResponse(resp) {
+		// Subscribers see the flow as soon as the headers arrive and get the
+		// completed version once the stream ends.
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		flow.Open = true
+		p.eng.AddFlow(flow)
+		if isEventStream(resp.Header) {
+			body = io.MultiWriter(respBody, p.newSSERecorder(flow.Id))
+		}
+	}
+	var src io.Reader = resp.Body
+	if fault != nil {
+		src = fault.TruncatedBody(resp.Body, resp.ContentLength)
+	}
+	if err := streamBody(w, src, body); err != nil {
+		flow.Error = err.Error()
+		if err == tamper.ErrTruncated {
+			// Ending the handler normally would complete a chunked body.
+			panic(http.ErrAbortHandler)
+		}
+	}
+	for k, vv := range resp.Trailer {
+		w.Header()[http.TrailerPrefix+k] = vv
 	}
-	log.Println("HTTP proxy server stopped")
-}
\ No newline at end of file
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+	"github.com/mnafshin/apix/pkg/tamper"
+)
+
+func newLocalMap(cfg config.MapLocalConfig) *tamper.LocalMap {
+	m := tamper.NewLocalMap()
+	for _, r := range cfg.Rules {
+		err := m.Add(tamper.LocalRule{URL: r.URL, Path: r.Path, Template: r.Template})
+		if err != nil {
+			log.Printf("Ignoring map local rule for %q: %v", r.URL, err)
+		}
+	}
+	return m
+}
+
+func newRemoteMap(cfg config.MapRemoteConfig) *tamper.RemoteMap {
+	m := tamper.NewRemoteMap()
+	for _, r := range cfg.Rules {
+		err := m.Add(tamper.RemoteRule{From: r.From, To: r.To, PreserveHost: r.PreserveHost})
+		if err != nil {
+			log.Printf("Ignoring map remote rule for %q: %v", r.From, err)
+		}
+	}
+	return m
+}
+
+// localTemplateData is the dot of Map Local templates, e.g.
+// {{.Query.Get "id"}} or {{.Header.Get "Authorization"}}.
+type localTemplateData struct {
+	Method string
+	URL    *url.URL
+	Host   string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// serveLocal answers r from file, which rule mapped target to, without
+// contacting upstream. Directories are served by their index.html. The
+// response body goes to rec.
+func (p *Proxy) serveLocal(w http.ResponseWriter, r *http.Request, target *url.URL, rule *tamper.LocalRule, file string, flow *apix.Flow, rec io.Writer) {
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		file = filepath.Join(file, "index.html")
+	}
+	flow.LocalFile = file
+	lw := &localResponse{ResponseWriter: w, flow: flow, rec: rec}
+
+	f, err := os.Open(file)
+	if err != nil {
+		log.Printf("Map local file for %s unavailable: %v", target, err)
+		http.Error(lw, "Not found locally: "+file, http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(lw, "Not found locally: "+file, http.StatusNotFound)
+		return
+	}
+
+	var content io.ReadSeeker = f
+	modTime := info.ModTime()
+	if rule.Template {
+		src, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(lw, "Failed to read "+file, http.StatusInternalServerError)
+			return
+		}
+		var out bytes.Buffer
+		tmpl, err := template.New(filepath.Base(file)).Parse(string(src))
+		if err == nil {
+			err = tmpl.Execute(&out, localTemplateData{
+				Method: r.Method,
+				URL:    target,
+				Host:   target.Host,
+				Path:   target.Path,
+				Query:  target.Query(),
+				Header: r.Header,
+			})
+		}
+		if err != nil {
+			log.Printf("Map local template %s failed: %v", file, err)
+			http.Error(lw, "Template error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// The output depends on the request, so it has no modification time.
+		content = bytes.NewReader(out.Bytes())
+		modTime = time.Time{}
+	}
+	// ServeContent picks the content type from the extension or by
+	// sniffing, and handles ranges and conditional requests.
+	http.ServeContent(lw, r, filepath.Base(file), modTime, content)
+}
+
+// localResponse records a response written by serveLocal on its flow.
+type localResponse struct {
+	http.ResponseWriter
+	flow *apix.Flow
+	rec  io.Writer
+}
+
+func (l *localResponse) WriteHeader(code int) {
+	l.flow.Response = &apix.HttpResponse{
+		StatusCode: int32(code),
+		HeaderList: headerList(l.Header()),
+	}
+	l.flow.Response.Headers = headerMap(l.flow.Response.HeaderList)
+	l.ResponseWriter.WriteHeader(code)
+}
+
+func (l *localResponse) Write(b []byte) (int, error) {
+	if l.flow.Response == nil {
+		l.WriteHeader(http.StatusOK)
+	}
+	n, err := l.ResponseWriter.Write(b)
+	l.rec.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// tunnelKey carries the tunnelTarget of an intercepted tunnel into the
+// context of every request served from it.
+type tunnelKey struct{}
+
+// tunnelTarget is the authority a CONNECT or SOCKS client asked for,
+// whether the intercepted stream was TLS, and the proxy user who opened the
+// tunnel, if any.
+type tunnelTarget struct {
+	authority string
+	tls       bool
+	user      string
+}
+
+// serveMITM terminates TLS on a hijacked tunnel with a leaf minted for the
+// requested host and serves the decrypted requests with the proxy handler.
+// It returns once the client connection is closed.
+func (p *Proxy) serveMITM(conn net.Conn, target tunnelTarget) {
+	authority := target.authority
+	target.tls = true
+	host, _, err := net.SplitHostPort(authority)
+	if err != nil {
+		host = authority
+	}
+
+	tlsConfig := p.ca.TLSConfig(host)
+	if p.http2 {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	tlsConn := tls.Server(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		log.Printf("TLS handshake with client for %s failed: %v", authority, err)
+		return
+	}
+
+	// The taps hide the *tls.Conn from http.Server, so decrypted requests
+	// are recognized by their tunnel target rather than by r.TLS, and an
+	// HTTP/2 session is served as cleartext HTTP/2 over the decrypted
+	// stream.
+	var tapped net.Conn = newHeaderTap(tlsConn)
+	protocols := new(http.Protocols)
+	if tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+		tapped = newH2Tap(tlsConn, false)
+		protocols.SetUnencryptedHTTP2(true)
+	} else {
+		protocols.SetHTTP1(true)
+	}
+
+	l := newOneConnListener(tapped)
+	srv := &http.Server{
+		Handler:   p,
+		Protocols: protocols,
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return withConnTap(context.WithValue(ctx, tunnelKey{}, target), tapped)
+		},
+	}
+	_ = srv.Serve(l)
+}
+
+// servePlain serves cleartext HTTP/1.x requests read from an intercepted
+// tunnel. It returns once the client connection is closed.
+func (p *Proxy) servePlain(conn net.Conn, target tunnelTarget) {
+	tapped := newHeaderTap(conn)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	srv := &http.Server{
+		Handler:   p,
+		Protocols: protocols,
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return withConnTap(context.WithValue(ctx, tunnelKey{}, target), tapped)
+		},
+	}
+	_ = srv.Serve(newOneConnListener(tapped))
+}
+
+// oneConnListener hands out a single connection and then blocks until it
+// is closed, so http.Server.Serve returns once that connection is done,
+// whether the server or a handler that hijacked it closes it.
+type oneConnListener struct {
+	conn     net.Conn
+	once     sync.Once
+	accepted bool
+	mu       sync.Mutex
+	done     chan struct{}
+}
+
+func newOneConnListener(conn net.Conn) *oneConnListener {
+	return &oneConnListener{conn: conn, done: make(chan struct{})}
+}
+
+func (l *oneConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if !l.accepted {
+		l.accepted = true
+		l.mu.Unlock()
+		return &closeNotifyConn{Conn: l.conn, l: l}, nil
+	}
+	l.mu.Unlock()
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *oneConnListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *oneConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// closeNotifyConn closes its listener when the connection is closed.
+type closeNotifyConn struct {
+	net.Conn
+	l *oneConnListener
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.Conn.Close()
+	c.l.Close()
+	return err
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http/httptrace"
+)
+
+// dialDirect resolves addr and connects to the first of its addresses that
+// accepts. The lookup is reported to the request's trace, as net.Dialer
+// would for its own lookups.
+func (u *upstream) dialDirect(ctx context.Context, addr string) (net.Conn, string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, "", err
+	}
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil && net.ParseIP(host) == nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	addrs, err := u.resolver.LookupHost(ctx, host)
+	if trace != nil && trace.DNSDone != nil && net.ParseIP(host) == nil {
+		info := httptrace.DNSDoneInfo{Err: err}
+		for _, a := range addrs {
+			info.Addrs = append(info.Addrs, net.IPAddr{IP: a.AsSlice(), Zone: a.Zone()})
+		}
+		trace.DNSDone(info)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var errs []error
+	for _, a := range addrs {
+		conn, err := u.dialer.DialContext(ctx, "tcp", net.JoinHostPort(a.String(), port))
+		if err == nil {
+			return conn, a.String(), nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// resolvedConn is an upstream connection that remembers the IP its host
+// was resolved to.
+type resolvedConn struct {
+	net.Conn
+	ip string
+}
+
+func (c *resolvedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// upstreamIP returns the IP the host of an upstream connection was resolved
+// to, looking through the taps and TLS layered on top of it.
+func upstreamIP(conn net.Conn) string {
+	for {
+		switch c := conn.(type) {
+		case *resolvedConn:
+			return c.ip
+		case *headerTap:
+			conn = c.Conn
+		case *tlsHeaderTap:
+			conn = c.headerTap.Conn
+		case *h2Tap:
+			conn = c.Conn
+		case *tls.Conn:
+			conn = c.NetConn()
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/utils"
+)
+
+// reverseProxy routes requests arriving on a reverse-proxy listener to
+// upstream services. Routed requests are handed to the forward proxy's
+// ServeHTTP so they are captured like any other flow.
+type reverseProxy struct {
+	p      *Proxy
+	routes []*reverseRoute
+}
+
+type reverseRoute struct {
+	host     string
+	prefix   string
+	upstream *url.URL
+}
+
+// reverseRouteKey carries the *reverseTarget of a routed request.
+type reverseRouteKey struct{}
+
+// reverseTarget remembers the client-facing origin of a routed request so
+// redirects from the upstream can be mapped back onto it.
+type reverseTarget struct {
+	route *reverseRoute
+	host  string // Host header sent by the client
+}
+
+func newReverseProxy(p *Proxy, cfg config.ReverseListener) (*reverseProxy, error) {
+	rp := &reverseProxy{p: p}
+	for _, r := range cfg.Routes {
+		u, err := url.Parse(r.Upstream)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", r.Upstream, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("route %q: upstream must be an absolute http or https URL", r.Upstream)
+		}
+		rp.routes = append(rp.routes, &reverseRoute{
+			host:     r.Host,
+			prefix:   strings.TrimSuffix(r.PathPrefix, "/"),
+			upstream: u,
+		})
+	}
+	return rp, nil
+}
+
+func (rp *reverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		http.Error(w, "CONNECT is not supported by a reverse proxy listener", http.StatusMethodNotAllowed)
+		return
+	}
+
+	route := rp.match(r)
+	if route == nil {
+		log.Printf("No reverse proxy route for %s%s", r.Host, r.URL.Path)
+		http.Error(w, "No route for request", http.StatusNotFound)
+		return
+	}
+
+	target := *route.upstream
+	target.Path = joinURLPath(route.upstream.Path, strings.TrimPrefix(r.URL.Path, route.prefix))
+	target.RawPath = ""
+	target.RawQuery = r.URL.RawQuery
+
+	ctx := context.WithValue(r.Context(), reverseRouteKey{}, &reverseTarget{route: route, host: r.Host})
+	routed := r.Clone(ctx)
+	routed.URL = &target
+	routed.Host = target.Host
+	rp.p.ServeHTTP(w, routed)
+}
+
+// match returns the most specific route for r: routes naming a host come
+// before catch-all routes, then longer prefixes before shorter ones.
+func (rp *reverseProxy) match(r *http.Request) *reverseRoute {
+	var best *reverseRoute
+	for _, route := range rp.routes {
+		if route.host != "" && !utils.MatchHost(route.host, r.Host) {
+			continue
+		}
+		if !hasPathPrefix(r.URL.Path, route.prefix) {
+			continue
+		}
+		if best == nil || route.moreSpecific(best) {
+			best = route
+		}
+	}
+	return best
+}
+
+func (r *reverseRoute) moreSpecific(other *reverseRoute) bool {
+	if (r.host != "") != (other.host != "") {
+		return r.host != ""
+	}
+	return len(r.prefix) > len(other.prefix)
+}
+
+// rewriteLocation maps a Location header pointing at the upstream back
+// onto the origin the client used.
+func (t *reverseTarget) rewriteLocation(h http.Header) {
+	loc, err := url.Parse(h.Get("Location"))
+	if err != nil || loc.Path == "" && loc.Host == "" {
+		return
+	}
+	upstream := t.route.upstream
+	if loc.IsAbs() && (loc.Scheme != upstream.Scheme || loc.Host != upstream.Host) {
+		return
+	}
+	if !loc.IsAbs() && (loc.Host != "" || !strings.HasPrefix(loc.Path, "/")) {
+		return
+	}
+	base := strings.TrimSuffix(upstream.Path, "/")
+	if !hasPathPrefix(loc.Path, base) {
+		return
+	}
+
+	if loc.IsAbs() {
+		loc.Scheme = "http"
+		loc.Host = t.host
+	}
+	loc.Path = joinURLPath(t.route.prefix, strings.TrimPrefix(loc.Path, base))
+	loc.RawPath = ""
+	h.Set("Location", loc.String())
+}
+
+// hasPathPrefix reports whether p lies under prefix on a segment boundary.
+func hasPathPrefix(p, prefix string) bool {
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func joinURLPath(a, b string) string {
+	switch {
+	case b == "":
+		if a == "" {
+			return "/"
+		}
+		return a
+	case strings.HasSuffix(a, "/") && strings.HasPrefix(b, "/"):
+		return a + b[1:]
+	case !strings.HasSuffix(a, "/") && !strings.HasPrefix(b, "/"):
+		return a + "/" + b
+	}
+	return a + b
+}
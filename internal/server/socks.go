@@ -0,0 +1,344 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+)
+
+const (
+	socks4Version = 0x04
+	socks5Version = 0x05
+
+	socksCmdConnect = 0x01
+
+	socks5AuthNone         = 0x00
+	socks5AuthPassword     = 0x02
+	socks5AuthNoAcceptable = 0xff
+	socks5PasswordVersion  = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+
+	socks5Succeeded           = 0x00
+	socks5HostUnreachable     = 0x04
+	socks5CommandNotSupported = 0x07
+	socks5AddrNotSupported    = 0x08
+
+	socks4Granted  = 0x5a
+	socks4Rejected = 0x5b
+
+	socksHandshakeTimeout = 10 * time.Second
+
+	// maxSOCKS4String bounds the user ID and host name of a SOCKS4a
+	// request, which are terminated by a NUL byte rather than sized.
+	maxSOCKS4String = 255
+
+	// sniffTimeout bounds how long a new tunnel waits for the client to
+	// speak first. Server-first protocols such as SMTP or MySQL never do, and
+	// are relayed uninterpreted once it expires.
+	sniffTimeout = 300 * time.Millisecond
+)
+
+// httpMethods are the request line prefixes that mark a tunneled stream as
+// cleartext HTTP/1.x.
+var httpMethods = []string{"GET ", "HEAD ", "POST ", "PUT ", "DELETE ", "OPTIONS ", "PATCH ", "TRACE "}
+
+// socksServer accepts SOCKS5 and SOCKS4a CONNECT requests. Streams that
+// turn out to be HTTP or TLS go through the proxy pipeline like CONNECT
+// tunnels do; anything else is relayed and recorded as a tunnel.
+type socksServer struct {
+	p     *Proxy
+	users *credentials // nil if clients need not authenticate
+}
+
+func StartSOCKSProxy(ctx context.Context, p *Proxy, cfg config.SOCKSConfig) {
+	lis, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		log.Printf("Failed to listen on :%s: %v", cfg.Port, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		lis.Close()
+	}()
+
+	log.Printf("Starting SOCKS proxy server on :%s", cfg.Port)
+	s := &socksServer{p: p, users: newCredentials(cfg.Users)}
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("SOCKS server error: %v", err)
+			}
+			break
+		}
+		go s.serve(conn)
+	}
+	log.Println("SOCKS proxy server stopped")
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+	start := time.Now()
+	conn.SetDeadline(start.Add(socksHandshakeTimeout))
+	br := bufio.NewReader(conn)
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return
+	}
+	var target tunnelTarget
+	var protocol string
+	switch version {
+	case socks5Version:
+		protocol = "SOCKS5"
+		target, err = s.handshake5(conn, br)
+	case socks4Version:
+		protocol = "SOCKS4a"
+		target, err = s.handshake4(conn, br)
+	default:
+		err = fmt.Errorf("unsupported SOCKS version %d", version)
+	}
+	if err != nil {
+		log.Printf("SOCKS handshake with %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	log.Printf("SOCKS proxy received request: %s %s", protocol, target.authority)
+
+	// The target is dialed before the client is told the tunnel is up, so
+	// failures are reported in the SOCKS reply.
+	upstream, err := s.p.dialTunnel(target.authority)
+	if err != nil {
+		log.Printf("Failed to dial tunnel target %s: %v", target.authority, err)
+		s.reply(conn, version, false)
+		return
+	}
+	if err := s.reply(conn, version, true); err != nil {
+		upstream.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	client := &bufferedConn{Conn: conn, r: br}
+	switch kind := sniff(conn, br); {
+	case kind == "tls" && s.p.ca != nil, kind == "http":
+		// The first request to the target goes over the connection already
+		// dialed for it.
+		defer s.p.upstream.adopt(target.authority, upstream)()
+		if kind == "tls" {
+			s.p.serveMITM(client, target)
+		} else {
+			s.p.servePlain(client, target)
+		}
+		return
+	}
+
+	defer upstream.Close()
+	out, in := pipe(conn, client, upstream)
+	s.p.recordTunnel(start, conn.RemoteAddr().String(), protocol, target, upstream, out, in)
+}
+
+// sniff waits briefly for the first bytes of a tunnel and reports whether
+// they start a TLS handshake or an HTTP/1.x request.
+func sniff(conn net.Conn, br *bufio.Reader) string {
+	conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	first, err := br.Peek(1)
+	if err != nil {
+		return ""
+	}
+	if first[0] == recordTypeHandshake {
+		return "tls"
+	}
+	head, _ := br.Peek(br.Buffered())
+	for _, method := range httpMethods {
+		if bytes.HasPrefix(head, []byte(method)) {
+			return "http"
+		}
+	}
+	return ""
+}
+
+// handshake5 negotiates authentication and reads the request of a SOCKS5
+// client (RFC 1928, RFC 1929), returning the requested host:port and the
+// authenticated user.
+func (s *socksServer) handshake5(conn net.Conn, br *bufio.Reader) (tunnelTarget, error) {
+	var target tunnelTarget
+	n, err := br.ReadByte()
+	if err != nil {
+		return target, err
+	}
+	methods := make([]byte, n)
+	if _, err := io.ReadFull(br, methods); err != nil {
+		return target, err
+	}
+	method := byte(socks5AuthNone)
+	if s.users != nil {
+		method = socks5AuthPassword
+	}
+	if bytes.IndexByte(methods, method) < 0 {
+		conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+		return target, errors.New("no acceptable authentication method")
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return target, err
+	}
+
+	if method == socks5AuthPassword {
+		if v, err := br.ReadByte(); err != nil || v != socks5PasswordVersion {
+			return target, errors.New("malformed username/password request")
+		}
+		username, err := readSOCKS5String(br)
+		if err != nil {
+			return target, err
+		}
+		password, err := readSOCKS5String(br)
+		if err != nil {
+			return target, err
+		}
+		target.user = username
+		if !s.users.check(username, password) {
+			conn.Write([]byte{socks5PasswordVersion, 0x01})
+			return target, fmt.Errorf("authentication failed for user %q", username)
+		}
+		if _, err := conn.Write([]byte{socks5PasswordVersion, 0x00}); err != nil {
+			return target, err
+		}
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(br, req[:]); err != nil {
+		return target, err
+	}
+	if req[1] != socksCmdConnect {
+		s.reply5(conn, socks5CommandNotSupported)
+		return target, fmt.Errorf("unsupported command %d", req[1])
+	}
+	var host string
+	switch req[3] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		ip := make(net.IP, 4)
+		if req[3] == socks5AddrIPv6 {
+			ip = make(net.IP, 16)
+		}
+		if _, err := io.ReadFull(br, ip); err != nil {
+			return target, err
+		}
+		host = ip.String()
+	case socks5AddrDomain:
+		if host, err = readSOCKS5String(br); err != nil {
+			return target, err
+		}
+	default:
+		s.reply5(conn, socks5AddrNotSupported)
+		return target, fmt.Errorf("unsupported address type %d", req[3])
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(br, port[:]); err != nil {
+		return target, err
+	}
+	target.authority = net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+	return target, nil
+}
+
+// handshake4 reads the request of a SOCKS4 or SOCKS4a client, returning
+// the requested host:port.
+func (s *socksServer) handshake4(conn net.Conn, br *bufio.Reader) (tunnelTarget, error) {
+	var target tunnelTarget
+	var req [7]byte
+	if _, err := io.ReadFull(br, req[:]); err != nil {
+		return target, err
+	}
+	if _, err := readSOCKS4String(br); err != nil { // user ID
+		return target, err
+	}
+	if req[0] != socksCmdConnect {
+		s.reply4(conn, socks4Rejected)
+		return target, fmt.Errorf("unsupported command %d", req[0])
+	}
+	if s.users != nil {
+		s.reply4(conn, socks4Rejected)
+		return target, errors.New("SOCKS4 cannot authenticate, but users are configured")
+	}
+
+	ip := net.IP(req[3:7])
+	host := ip.String()
+	// SOCKS4a marks a host name following the user ID with 0.0.0.x.
+	if ip[0] == 0 && ip[1] == 0 && ip[2] == 0 && ip[3] != 0 {
+		name, err := readSOCKS4String(br)
+		if err != nil {
+			return target, err
+		}
+		host = name
+	}
+	target.authority = net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(req[1:3]))))
+	return target, nil
+}
+
+func (s *socksServer) reply(conn net.Conn, version byte, ok bool) error {
+	if version == socks4Version {
+		if ok {
+			return s.reply4(conn, socks4Granted)
+		}
+		return s.reply4(conn, socks4Rejected)
+	}
+	if ok {
+		return s.reply5(conn, socks5Succeeded)
+	}
+	return s.reply5(conn, socks5HostUnreachable)
+}
+
+// reply5 answers a SOCKS5 request. The bound address is left unspecified
+// since clients of CONNECT do not use it.
+func (s *socksServer) reply5(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{socks5Version, code, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+func (s *socksServer) reply4(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{0, code, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+func readSOCKS5String(br *bufio.Reader) (string, error) {
+	n, err := br.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readSOCKS4String reads a NUL-terminated SOCKS4a string.
+func readSOCKS4String(br *bufio.Reader) (string, error) {
+	var b []byte
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			return string(b), nil
+		}
+		if len(b) == maxSOCKS4String {
+			return "", fmt.Errorf("SOCKS4a string longer than %d bytes", maxSOCKS4String)
+		}
+		b = append(b, c)
+	}
+}
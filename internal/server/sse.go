@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// isStreamingResponse reports whether the response body is produced over
+// time rather than known up front: server-sent events and bodies without a
+// declared length, such as chunked HTTP/1.1 responses.
+func isStreamingResponse(resp *http.Response) bool {
+	return isEventStream(resp.Header) || resp.ContentLength < 0
+}
+
+func isEventStream(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType == "text/event-stream" && h.Get("Content-Encoding") == ""
+}
+
+// sseRecorder parses a text/event-stream body as it is relayed and records
+// every dispatched event in the engine. Parsing stops once the body
+// capture cap has been read. Like bodyRecorder, writes never fail.
+type sseRecorder struct {
+	p      *Proxy
+	flowID string
+
+	line   []byte
+	skipLF bool // the previous line ended in CR, so a following LF is part of it
+	read   int64
+	event  *apix.ServerSentEvent
+	data   []string
+	lastID string // carried over to later events that have no id field
+}
+
+func (p *Proxy) newSSERecorder(flowID string) *sseRecorder {
+	return &sseRecorder{p: p, flowID: flowID}
+}
+
+func (s *sseRecorder) Write(b []byte) (int, error) {
+	n := len(b)
+	if s.read >= s.p.capture.MaxBodyBytes {
+		return n, nil
+	}
+	s.read += int64(n)
+
+	for len(b) > 0 {
+		if s.skipLF {
+			s.skipLF = false
+			if b[0] == '\n' {
+				b = b[1:]
+				continue
+			}
+		}
+		i := bytes.IndexAny(b, "\r\n")
+		if i < 0 {
+			s.line = append(s.line, b...)
+			break
+		}
+		s.line = append(s.line, b[:i]...)
+		s.skipLF = b[i] == '\r'
+		b = b[i+1:]
+		s.processLine(string(s.line))
+		s.line = s.line[:0]
+	}
+	return n, nil
+}
+
+// processLine interprets one line of the stream as described in the HTML
+// specification's event stream interpretation.
+func (s *sseRecorder) processLine(line string) {
+	if line == "" {
+		s.dispatch()
+		return
+	}
+	if strings.HasPrefix(line, ":") {
+		return
+	}
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+
+	if s.event == nil {
+		s.event = &apix.ServerSentEvent{FlowId: s.flowID}
+	}
+	switch field {
+	case "event":
+		s.event.Event = value
+	case "data":
+		s.data = append(s.data, value)
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			s.lastID = value
+		}
+	case "retry":
+		if retry, err := strconv.ParseInt(value, 10, 64); err == nil {
+			s.event.Retry = retry
+		}
+	}
+}
+
+func (s *sseRecorder) dispatch() {
+	event := s.event
+	if event == nil {
+		return
+	}
+	event.Id = s.lastID
+	event.Data = strings.Join(s.data, "\n")
+	s.event, s.data = nil, s.data[:0]
+	event.TimestampMs = time.Now().UnixMilli()
+	s.p.eng.AddServerSentEvent(event)
+}
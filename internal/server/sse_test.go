@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/engine"
+)
+
+func TestSSERecorder(t *testing.T) {
+	eng := engine.New(config.RetentionConfig{}, config.SubscribersConfig{Policy: engine.DropNewest, BufferSize: 1})
+	defer eng.Close()
+	events := eng.SubscribeServerSentEvents()
+	p := &Proxy{eng: eng, capture: config.CaptureConfig{MaxBodyBytes: 1 << 20}}
+
+	// The stream is split mid-line and mixes line endings.
+	stream := []string{
+		"id: 1\nevent: greeting\ndata: hel",
+		"lo\r\ndata: world\r\n\r\n",
+		": comment\ndata: no id of its own\n\n",
+		"id\ndata: id reset\n\n",
+		"id: 7\rretry: 3000\rdata: x\r\r",
+	}
+	rec := p.newSSERecorder("f1")
+	for _, chunk := range stream {
+		rec.Write([]byte(chunk))
+	}
+
+	want := []string{
+		`1 greeting "hello\nworld" 0`,
+		`1  "no id of its own" 0`,
+		`  "id reset" 0`,
+		`7  "x" 3000`,
+	}
+	var got []string
+	for range want {
+		e := <-events
+		got = append(got, fmt.Sprintf("%s %s %q %d", e.Id, e.Event, e.Data, e.Retry))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got events\n%q\nwant\n%q", got, want)
+	}
+}
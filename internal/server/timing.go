@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// phaseTimer records when each phase of an upstream request starts and
+// ends. Connects may be attempted several times, so the first start and the
+// last end of a phase win.
+type phaseTimer struct {
+	mu    sync.Mutex
+	start time.Time
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn                   time.Time
+	wroteRequest              time.Time
+	firstByte                 time.Time
+	reused                    bool
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{start: time.Now()}
+}
+
+func (t *phaseTimer) trace() *httptrace.ClientTrace {
+	first := func(at *time.Time) {
+		t.mu.Lock()
+		if at.IsZero() {
+			*at = time.Now()
+		}
+		t.mu.Unlock()
+	}
+	last := func(at *time.Time) {
+		t.mu.Lock()
+		*at = time.Now()
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { first(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { last(&t.dnsDone) },
+		ConnectStart:      func(string, string) { first(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { last(&t.connectDone) },
+		TLSHandshakeStart: func() { first(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { last(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			first(&t.gotConn)
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { last(&t.wroteRequest) },
+		GotFirstResponseByte: func() { first(&t.firstByte) },
+	}
+}
+
+// timing returns the phase durations of a request whose response body was
+// done at end.
+func (t *phaseTimer) timing(end time.Time) *apix.Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	writeStart := t.gotConn
+	if writeStart.IsZero() {
+		writeStart = t.start
+	}
+	return &apix.Timing{
+		DnsUs:            microsBetween(t.dnsStart, t.dnsDone),
+		ConnectUs:        microsBetween(t.connectStart, t.connectDone),
+		TlsUs:            microsBetween(t.tlsStart, t.tlsDone),
+		RequestWriteUs:   microsBetween(writeStart, t.wroteRequest),
+		TtfbUs:           microsBetween(t.wroteRequest, t.firstByte),
+		TransferUs:       microsBetween(t.firstByte, end),
+		ConnectionReused: t.reused,
+	}
+}
+
+func microsBetween(from, to time.Time) int64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from).Microseconds()
+}
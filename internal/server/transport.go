@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/netsim"
+	"github.com/mnafshin/apix/internal/resolver"
+	"github.com/mnafshin/apix/internal/utils"
+	"golang.org/x/net/http2"
+)
+
+// upstream sends proxied requests to their destination. HTTPS origins are
+// offered HTTP/2 through ALPN and remembered as HTTP/1-only when they
+// decline it; hosts listed for h2c are spoken to with cleartext HTTP/2.
+// Every connection is tapped so captured responses keep the headers and
+// stream IDs the origin sent.
+type upstream struct {
+	dialer      *net.Dialer
+	h1          *http.Transport
+	h2          *http2.Transport
+	http2       bool
+	h2cHosts    []string
+	routes      []*proxyRoute
+	network     *netsim.Simulator
+	resolver    *resolver.Resolver
+	tlsPolicies []*tlsPolicy
+
+	mu      sync.Mutex
+	h1Only  map[string]bool
+	conns   map[string][]*h2Conn
+	adopted map[string][]net.Conn // dialed connections for the next dial to their address
+}
+
+// h2Conn is a pooled HTTP/2 client connection and the tap beneath it.
+type h2Conn struct {
+	cc  *http2.ClientConn
+	tap *h2Tap
+}
+
+// upstreamResult describes the connection that carried a request.
+type upstreamResult struct {
+	conn   net.Conn // *headerTap or *tlsHeaderTap for HTTP/1.x
+	h2     *h2Tap
+	stream uint32
+}
+
+func newUpstream(cfg *config.Config, network *netsim.Simulator, dns *resolver.Resolver) *upstream {
+	u := &upstream{
+		dialer:      &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		h2:          &http2.Transport{IdleConnTimeout: 90 * time.Second},
+		http2:       cfg.HTTP2.Enabled,
+		h2cHosts:    cfg.HTTP2.H2CHosts,
+		routes:      newProxyRoutes(cfg.UpstreamProxy),
+		network:     network,
+		resolver:    dns,
+		tlsPolicies: newTLSPolicies(cfg.UpstreamTLS),
+		h1Only:      make(map[string]bool),
+		conns:       make(map[string][]*h2Conn),
+		adopted:     make(map[string][]net.Conn),
+	}
+	u.h1 = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := u.dial(ctx, addr)
+			if err != nil {
+				return nil, err
+			}
+			return newHeaderTap(conn), nil
+		},
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			tlsConn, err := u.dialTLS(ctx, addr, []string{"http/1.1"})
+			if err != nil {
+				return nil, err
+			}
+			return &tlsHeaderTap{headerTap: newHeaderTap(tlsConn), tlsConn: tlsConn}, nil
+		},
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return u
+}
+
+// roundTrip sends req and reports which connection carried it.
+func (u *upstream) roundTrip(req *http.Request) (*http.Response, *upstreamResult, error) {
+	authority := canonicalAuthority(req)
+
+	// Protocol upgrades such as WebSocket only exist in HTTP/1.1.
+	var cc *h2Conn
+	var reused bool
+	if req.Header.Get("Upgrade") == "" {
+		var err error
+		if cc, reused, err = u.h2ConnFor(req.Context(), req.URL.Scheme, authority); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cc != nil {
+		// ClientConn.RoundTrip leaves connection tracing to the pool.
+		if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.GotConn != nil {
+			trace.GotConn(httptrace.GotConnInfo{Conn: cc.tap, Reused: reused})
+		}
+		// The client calls WroteHeaders holding the connection's write
+		// lock, right after it flushed the block that opened the stream. It
+		// may do so after a failed RoundTrip returned, which leaves the
+		// stream to be released there.
+		var mu sync.Mutex
+		var stream uint32
+		var failed bool
+		wrote := make(chan struct{})
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			WroteHeaders: func() {
+				mu.Lock()
+				stream, _ = cc.tap.claimOpened()
+				if failed && stream != 0 {
+					cc.tap.release(stream)
+				}
+				mu.Unlock()
+				close(wrote)
+			},
+		}))
+		resp, err := cc.cc.RoundTrip(req)
+		if err != nil {
+			mu.Lock()
+			failed = true
+			if stream != 0 {
+				cc.tap.release(stream)
+			}
+			mu.Unlock()
+			return nil, nil, err
+		}
+		// A response means the headers were written.
+		<-wrote
+		return resp, &upstreamResult{h2: cc.tap, stream: stream}, nil
+	}
+
+	result := &upstreamResult{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { result.conn = info.Conn },
+	}))
+	resp, err := u.h1.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, result, nil
+}
+
+// h2ConnFor returns a pooled or new HTTP/2 connection to authority, or nil
+// if the request has to go over HTTP/1.x. reused reports a pooled one.
+func (u *upstream) h2ConnFor(ctx context.Context, scheme, authority string) (c *h2Conn, reused bool, err error) {
+	h2c := scheme == "http" && u.isH2CHost(authority)
+	if !h2c && (scheme != "https" || !u.http2) {
+		return nil, false, nil
+	}
+
+	u.mu.Lock()
+	if u.h1Only[authority] {
+		u.mu.Unlock()
+		return nil, false, nil
+	}
+	// Connections closed, e.g. after idling, are dropped from every pool.
+	var found *h2Conn
+	for a, conns := range u.conns {
+		conns = slices.DeleteFunc(conns, func(c *h2Conn) bool { return c.cc.State().Closed })
+		if len(conns) == 0 {
+			delete(u.conns, a)
+			continue
+		}
+		u.conns[a] = conns
+	}
+	for _, c := range u.conns[authority] {
+		if c.cc.CanTakeNewRequest() {
+			found = c
+			break
+		}
+	}
+	u.mu.Unlock()
+	if found != nil {
+		return found, true, nil
+	}
+
+	var conn net.Conn
+	if h2c {
+		if conn, err = u.dial(ctx, authority); err != nil {
+			return nil, false, err
+		}
+	} else {
+		tlsConn, err := u.dialTLS(ctx, authority, []string{"h2", "http/1.1"})
+		if err != nil {
+			return nil, false, err
+		}
+		if tlsConn.ConnectionState().NegotiatedProtocol != "h2" {
+			// The origin only speaks HTTP/1.x; the HTTP/1 transport dials its
+			// own connections from now on.
+			tlsConn.Close()
+			u.mu.Lock()
+			u.h1Only[authority] = true
+			u.mu.Unlock()
+			return nil, false, nil
+		}
+		conn = tlsConn
+	}
+
+	tap := newH2Tap(conn, true)
+	cc, err := u.h2.NewClientConn(tap)
+	if err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	c = &h2Conn{cc: cc, tap: tap}
+	u.mu.Lock()
+	u.conns[authority] = append(u.conns[authority], c)
+	u.mu.Unlock()
+	return c, false, nil
+}
+
+func (u *upstream) dialTLS(ctx context.Context, addr string, nextProtos []string) (*tls.Conn, error) {
+	conn, err := u.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	// The transports only trace handshakes they perform themselves.
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	tlsConn := tls.Client(conn, u.tlsConfig(addr, nextProtos))
+	err = tlsConn.HandshakeContext(ctx)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (u *upstream) isH2CHost(authority string) bool {
+	for _, pattern := range u.h2cHosts {
+		if utils.MatchHost(pattern, authority) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkProfile names the simulated network connections to addr go
+// through, if any.
+func (u *upstream) networkProfile(addr string) string {
+	if p, ok := u.network.ProfileFor(addr); ok {
+		return p.Name
+	}
+	return ""
+}
+
+// canonicalAuthority returns the host:port the request is sent to.
+func canonicalAuthority(req *http.Request) string {
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port)
+}
+
+// stripDefaultPort drops the port from authority when it is the default
+// for scheme, so URLs built from tunnel targets read as clients wrote them.
+func stripDefaultPort(authority, scheme string) string {
+	host, port, err := net.SplitHostPort(authority)
+	if err != nil {
+		return authority
+	}
+	if scheme == "http" && port == "80" || scheme == "https" && port == "443" {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return authority
+}
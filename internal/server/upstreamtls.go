@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/mnafshin/apix/internal/config"
+	"github.com/mnafshin/apix/internal/utils"
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// tlsVersions maps configured minimum versions to their constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsPolicy is the TLS configuration for connections to hosts.
+type tlsPolicy struct {
+	hosts  []string
+	config *tls.Config
+}
+
+func newTLSPolicies(cfg config.UpstreamTLSConfig) []*tlsPolicy {
+	var policies []*tlsPolicy
+	for _, rule := range cfg.Rules {
+		tc, err := newTLSConfig(rule)
+		if err != nil {
+			log.Printf("Ignoring upstream TLS rule for %v: %v", rule.Hosts, err)
+			continue
+		}
+		policies = append(policies, &tlsPolicy{hosts: rule.Hosts, config: tc})
+	}
+	return policies
+}
+
+func newTLSConfig(rule config.UpstreamTLSRule) (*tls.Config, error) {
+	tc := &tls.Config{
+		InsecureSkipVerify: rule.InsecureSkipVerify,
+		ServerName:         rule.ServerName,
+	}
+	if rule.MinVersion != "" {
+		v, ok := tlsVersions[rule.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q", rule.MinVersion)
+		}
+		tc.MinVersion = v
+	}
+	if rule.ClientCert != "" || rule.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(rule.ClientCert, rule.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	if rule.CABundle != "" {
+		pem, err := os.ReadFile(rule.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", rule.CABundle)
+		}
+	}
+	return tc, nil
+}
+
+// tlsConfig returns the TLS configuration for a connection to addr.
+func (u *upstream) tlsConfig(addr string, nextProtos []string) *tls.Config {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	tc := &tls.Config{}
+	for _, p := range u.tlsPolicies {
+		if matchAnyHost(p.hosts, addr) {
+			tc = p.config.Clone()
+			break
+		}
+	}
+	if tc.ServerName == "" {
+		tc.ServerName = host
+	}
+	tc.NextProtos = nextProtos
+	return tc
+}
+
+func matchAnyHost(patterns []string, addr string) bool {
+	for _, pattern := range patterns {
+		if utils.MatchHost(pattern, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamTLS describes the TLS connection a response arrived on, if any.
+// The HTTP/1 transport reports it on the response; HTTP/2 connections are
+// found under their tap.
+func upstreamTLS(resp *http.Response, res *upstreamResult) *apix.TLSInfo {
+	state := resp.TLS
+	if state == nil && res.h2 != nil {
+		if tc, ok := res.h2.Conn.(*tls.Conn); ok {
+			cs := tc.ConnectionState()
+			state = &cs
+		}
+	}
+	if state == nil {
+		return nil
+	}
+
+	info := &apix.TLSInfo{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		Resumed:            state.DidResume,
+	}
+	for _, cert := range state.PeerCertificates {
+		sum := sha256.Sum256(cert.Raw)
+		info.PeerCertificates = append(info.PeerCertificates, &apix.Certificate{
+			Subject:           cert.Subject.String(),
+			Issuer:            cert.Issuer.String(),
+			SerialNumber:      cert.SerialNumber.String(),
+			NotBeforeMs:       cert.NotBefore.UnixMilli(),
+			NotAfterMs:        cert.NotAfter.UnixMilli(),
+			DnsNames:          cert.DNSNames,
+			Sha256Fingerprint: hex.EncodeToString(sum[:]),
+			Der:               cert.Raw,
+		})
+	}
+	return info
+}
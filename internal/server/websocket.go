@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apix "github.com/mnafshin/apix/pkg/api/generated"
+)
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket
+// protocol.
+func isWebSocketUpgrade(h http.Header) bool {
+	return headerHasToken(h, "Connection", "upgrade") && headerHasToken(h, "Upgrade", "websocket")
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// relayWebSocket completes a WebSocket upgrade that the upstream accepted:
+// it hijacks the client connection, forwards the 101 response and relays
+// bytes in both directions while recording every frame under flowID.
+func (p *Proxy) relayWebSocket(w http.ResponseWriter, r *http.Request, resp *http.Response, flowID string) {
+	upstream, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		log.Printf("Upgraded response from %s is not writable", r.Host)
+		return
+	}
+	defer upstream.Close()
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		log.Printf("Response writer for %s does not support hijacking", r.Host)
+		return
+	}
+	client, rw, err := hj.Hijack()
+	if err != nil {
+		log.Printf("Failed to hijack WebSocket connection for %s: %v", r.Host, err)
+		return
+	}
+	defer client.Close()
+	stopConnTap(r)
+
+	header := resp.Header.Clone()
+	removeHopByHop(header, true)
+	if p.forwarding.Via {
+		header.Add("Via", viaValue(resp.ProtoMajor, resp.ProtoMinor))
+	}
+	rw.WriteString("HTTP/1.1 " + resp.Status + "\r\n")
+	header.Write(rw)
+	rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		log.Printf("Failed to forward WebSocket handshake for %s: %v", r.Host, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		rec := p.newFrameRecorder(flowID, apix.FrameDirection_CLIENT_TO_SERVER)
+		io.Copy(upstream, io.TeeReader(rw.Reader, rec))
+		upstream.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		rec := p.newFrameRecorder(flowID, apix.FrameDirection_SERVER_TO_CLIENT)
+		io.Copy(client, io.TeeReader(upstream, rec))
+		client.Close()
+	}()
+	wg.Wait()
+}
+
+// frameRecorder parses one direction of a WebSocket stream and records
+// each frame in the engine. Payloads are kept up to the body capture cap.
+// Like bodyRecorder, writes never fail.
+type frameRecorder struct {
+	p         *Proxy
+	flowID    string
+	direction apix.FrameDirection
+
+	header []byte // partial frame header
+	frame  *apix.WebSocketFrame
+	mask   []byte
+	remain int64 // payload bytes still to come
+}
+
+func (p *Proxy) newFrameRecorder(flowID string, direction apix.FrameDirection) *frameRecorder {
+	return &frameRecorder{p: p, flowID: flowID, direction: direction}
+}
+
+func (f *frameRecorder) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		if f.frame == nil {
+			f.header = append(f.header, b[0])
+			b = b[1:]
+			f.parseHeader()
+			continue
+		}
+
+		chunk := b[:min(int64(len(b)), f.remain)]
+		b = b[len(chunk):]
+		f.remain -= int64(len(chunk))
+		if room := f.p.capture.MaxBodyBytes - int64(len(f.frame.Payload)); room > 0 {
+			start := len(f.frame.Payload)
+			f.frame.Payload = append(f.frame.Payload, chunk[:min(int64(len(chunk)), room)]...)
+			if f.mask != nil {
+				for i := start; i < len(f.frame.Payload); i++ {
+					f.frame.Payload[i] ^= f.mask[i%4]
+				}
+			}
+		}
+		if f.remain == 0 {
+			f.emit()
+		}
+	}
+	return n, nil
+}
+
+// parseHeader decodes the frame header once enough bytes have arrived.
+func (f *frameRecorder) parseHeader() {
+	h := f.header
+	if len(h) < 2 {
+		return
+	}
+	need := 2
+	switch h[1] & 0x7f {
+	case 126:
+		need += 2
+	case 127:
+		need += 8
+	}
+	masked := h[1]&0x80 != 0
+	if masked {
+		need += 4
+	}
+	if len(h) < need {
+		return
+	}
+
+	var size int64
+	switch l := h[1] & 0x7f; l {
+	case 126:
+		size = int64(binary.BigEndian.Uint16(h[2:]))
+	case 127:
+		size = int64(binary.BigEndian.Uint64(h[2:]) & (1<<63 - 1))
+	default:
+		size = int64(l)
+	}
+
+	f.frame = &apix.WebSocketFrame{
+		FlowId:      f.flowID,
+		Direction:   f.direction,
+		Opcode:      int32(h[0] & 0x0f),
+		Fin:         h[0]&0x80 != 0,
+		PayloadSize: size,
+		TimestampMs: time.Now().UnixMilli(),
+	}
+	f.mask = nil
+	if masked {
+		f.mask = append([]byte(nil), h[need-4:need]...)
+	}
+	f.remain = size
+	f.header = f.header[:0]
+	if size == 0 {
+		f.emit()
+	}
+}
+
+func (f *frameRecorder) emit() {
+	f.frame.PayloadTruncated = int64(len(f.frame.Payload)) < f.frame.PayloadSize
+	f.p.eng.AddWebSocketFrame(f.frame)
+	f.frame = nil
+}
@@ -0,0 +1,18 @@
+// Package utils holds small helpers shared by the proxy's packages.
+package utils
+
+import (
+	"net"
+	"path"
+)
+
+// MatchHost reports whether authority matches pattern, either a glob
+// pattern for the host name or an exact host:port.
+func MatchHost(pattern, authority string) bool {
+	host, _, err := net.SplitHostPort(authority)
+	if err != nil {
+		host = authority
+	}
+	ok, _ := path.Match(pattern, host)
+	return ok || pattern == authority
+}
@@ -21,33 +21,2199 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Direction of a WebSocket frame
+type FrameDirection int32
+
+const (
+	FrameDirection_CLIENT_TO_SERVER FrameDirection = 0
+	FrameDirection_SERVER_TO_CLIENT FrameDirection = 1
+)
+
+// Enum value maps for FrameDirection.
+var (
+	FrameDirection_name = map[int32]string{
+		0: "CLIENT_TO_SERVER",
+		1: "SERVER_TO_CLIENT",
+	}
+	FrameDirection_value = map[string]int32{
+		"CLIENT_TO_SERVER": 0,
+		"SERVER_TO_CLIENT": 1,
+	}
+)
+
+func (x FrameDirection) Enum() *FrameDirection {
+	p := new(FrameDirection)
+	*p = x
+	return p
+}
+
+func (x FrameDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FrameDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_apix_proto_enumTypes[0].Descriptor()
+}
+
+func (FrameDirection) Type() protoreflect.EnumType {
+	return &file_apix_proto_enumTypes[0]
+}
+
+func (x FrameDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FrameDirection.Descriptor instead.
+func (FrameDirection) EnumDescriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{0}
+}
+
+// A header field as it appeared on the wire
+type Header struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Header) Reset() {
+	*x = Header{}
+	mi := &file_apix_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Header) ProtoMessage() {}
+
+func (x *Header) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Header.ProtoReflect.Descriptor instead.
+func (*Header) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Header) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Header) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
 // A single HTTP request captured by the proxy
 type HttpRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
-	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
-	Headers       map[string]string      `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Body          string                 `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Headers       map[string]string      `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // first value per canonical name, see header_list
+	Body          []byte                 `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	BodySize      int64                  `protobuf:"varint,6,opt,name=body_size,json=bodySize,proto3" json:"body_size,omitempty"`                // bytes seen on the wire, even past the capture cap
+	BodyTruncated bool                   `protobuf:"varint,7,opt,name=body_truncated,json=bodyTruncated,proto3" json:"body_truncated,omitempty"` // body holds only the first bytes up to the cap
+	BodyFile      string                 `protobuf:"bytes,8,opt,name=body_file,json=bodyFile,proto3" json:"body_file,omitempty"`                 // set instead of body when the capture spilled to disk
+	HeaderList    []*Header              `protobuf:"bytes,9,rep,name=header_list,json=headerList,proto3" json:"header_list,omitempty"`           // every header in original order and casing
+	Trailers      []*Header              `protobuf:"bytes,10,rep,name=trailers,proto3" json:"trailers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpRequest) Reset() {
+	*x = HttpRequest{}
+	mi := &file_apix_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpRequest) ProtoMessage() {}
+
+func (x *HttpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpRequest.ProtoReflect.Descriptor instead.
+func (*HttpRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HttpRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *HttpRequest) GetBodySize() int64 {
+	if x != nil {
+		return x.BodySize
+	}
+	return 0
+}
+
+func (x *HttpRequest) GetBodyTruncated() bool {
+	if x != nil {
+		return x.BodyTruncated
+	}
+	return false
+}
+
+func (x *HttpRequest) GetBodyFile() string {
+	if x != nil {
+		return x.BodyFile
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetHeaderList() []*Header {
+	if x != nil {
+		return x.HeaderList
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetTrailers() []*Header {
+	if x != nil {
+		return x.Trailers
+	}
+	return nil
+}
+
+// A single HTTP response captured by the proxy
+type HttpResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StatusCode    int32                  `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Headers       map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // first value per canonical name, see header_list
+	Body          []byte                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	BodySize      int64                  `protobuf:"varint,4,opt,name=body_size,json=bodySize,proto3" json:"body_size,omitempty"`
+	BodyTruncated bool                   `protobuf:"varint,5,opt,name=body_truncated,json=bodyTruncated,proto3" json:"body_truncated,omitempty"`
+	BodyFile      string                 `protobuf:"bytes,6,opt,name=body_file,json=bodyFile,proto3" json:"body_file,omitempty"`
+	HeaderList    []*Header              `protobuf:"bytes,7,rep,name=header_list,json=headerList,proto3" json:"header_list,omitempty"`
+	Trailers      []*Header              `protobuf:"bytes,8,rep,name=trailers,proto3" json:"trailers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpResponse) Reset() {
+	*x = HttpResponse{}
+	mi := &file_apix_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpResponse) ProtoMessage() {}
+
+func (x *HttpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpResponse.ProtoReflect.Descriptor instead.
+func (*HttpResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HttpResponse) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *HttpResponse) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *HttpResponse) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *HttpResponse) GetBodySize() int64 {
+	if x != nil {
+		return x.BodySize
+	}
+	return 0
+}
+
+func (x *HttpResponse) GetBodyTruncated() bool {
+	if x != nil {
+		return x.BodyTruncated
+	}
+	return false
+}
+
+func (x *HttpResponse) GetBodyFile() string {
+	if x != nil {
+		return x.BodyFile
+	}
+	return ""
+}
+
+func (x *HttpResponse) GetHeaderList() []*Header {
+	if x != nil {
+		return x.HeaderList
+	}
+	return nil
+}
+
+func (x *HttpResponse) GetTrailers() []*Header {
+	if x != nil {
+		return x.Trailers
+	}
+	return nil
+}
+
+// A CONNECT tunnel relayed by the proxy without decryption
+type Tunnel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	BytesIn       int64                  `protobuf:"varint,2,opt,name=bytes_in,json=bytesIn,proto3" json:"bytes_in,omitempty"`    // bytes sent by the target to the client
+	BytesOut      int64                  `protobuf:"varint,3,opt,name=bytes_out,json=bytesOut,proto3" json:"bytes_out,omitempty"` // bytes sent by the client to the target
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Protocol      string                 `protobuf:"bytes,6,opt,name=protocol,proto3" json:"protocol,omitempty"` // "CONNECT", "SOCKS5" or "SOCKS4a"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tunnel) Reset() {
+	*x = Tunnel{}
+	mi := &file_apix_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tunnel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tunnel) ProtoMessage() {}
+
+func (x *Tunnel) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tunnel.ProtoReflect.Descriptor instead.
+func (*Tunnel) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Tunnel) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Tunnel) GetBytesIn() int64 {
+	if x != nil {
+		return x.BytesIn
+	}
+	return 0
+}
+
+func (x *Tunnel) GetBytesOut() int64 {
+	if x != nil {
+		return x.BytesOut
+	}
+	return 0
+}
+
+func (x *Tunnel) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Tunnel) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Tunnel) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+// A request/response exchange captured by the proxy. Tunnels that were
+// relayed without decryption are flows with only the tunnel field set, and
+// flows a subscriber missed are marked by flows with only the gap field set.
+type Flow struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Request          *HttpRequest           `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	Response         *HttpResponse          `protobuf:"bytes,3,opt,name=response,proto3" json:"response,omitempty"`
+	StartedAtMs      int64                  `protobuf:"varint,4,opt,name=started_at_ms,json=startedAtMs,proto3" json:"started_at_ms,omitempty"`    // unix milliseconds
+	FinishedAtMs     int64                  `protobuf:"varint,5,opt,name=finished_at_ms,json=finishedAtMs,proto3" json:"finished_at_ms,omitempty"` // unix milliseconds
+	DurationMs       int64                  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Error            string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	ClientAddr       string                 `protobuf:"bytes,8,opt,name=client_addr,json=clientAddr,proto3" json:"client_addr,omitempty"`
+	Tunnel           *Tunnel                `protobuf:"bytes,9,opt,name=tunnel,proto3" json:"tunnel,omitempty"`
+	ClientProtocol   string                 `protobuf:"bytes,10,opt,name=client_protocol,json=clientProtocol,proto3" json:"client_protocol,omitempty"` // e.g. "HTTP/1.1" or "HTTP/2.0"
+	UpstreamProtocol string                 `protobuf:"bytes,11,opt,name=upstream_protocol,json=upstreamProtocol,proto3" json:"upstream_protocol,omitempty"`
+	ClientStreamId   uint32                 `protobuf:"varint,12,opt,name=client_stream_id,json=clientStreamId,proto3" json:"client_stream_id,omitempty"`       // HTTP/2 stream on the client connection
+	UpstreamStreamId uint32                 `protobuf:"varint,13,opt,name=upstream_stream_id,json=upstreamStreamId,proto3" json:"upstream_stream_id,omitempty"` // HTTP/2 stream on the upstream connection
+	Open             bool                   `protobuf:"varint,14,opt,name=open,proto3" json:"open,omitempty"`                                                   // response is still streaming; an update follows
+	SseEvents        []*ServerSentEvent     `protobuf:"bytes,15,rep,name=sse_events,json=sseEvents,proto3" json:"sse_events,omitempty"`
+	// How the upstream was reached: "direct" or the chained proxy, e.g.
+	// "socks5://proxy.example:1080".
+	UpstreamRoute  string  `protobuf:"bytes,16,opt,name=upstream_route,json=upstreamRoute,proto3" json:"upstream_route,omitempty"`
+	Timing         *Timing `protobuf:"bytes,17,opt,name=timing,proto3" json:"timing,omitempty"`
+	ProxyUser      string  `protobuf:"bytes,18,opt,name=proxy_user,json=proxyUser,proto3" json:"proxy_user,omitempty"`                // authenticated proxy user, if any
+	NetworkProfile string  `protobuf:"bytes,19,opt,name=network_profile,json=networkProfile,proto3" json:"network_profile,omitempty"` // simulated network the upstream leg went through
+	Fault          *Fault  `protobuf:"bytes,20,opt,name=fault,proto3" json:"fault,omitempty"`                                         // injected failure, if any
+	LocalFile      string  `protobuf:"bytes,21,opt,name=local_file,json=localFile,proto3" json:"local_file,omitempty"`                // file the response was served from instead of upstream (Map Local)
+	RemoteUrl      string  `protobuf:"bytes,22,opt,name=remote_url,json=remoteUrl,proto3" json:"remote_url,omitempty"`                // URL request.url was rewritten to by Map Remote
+	// Upstream IP the request went to; empty when a chained proxy resolved
+	// the host.
+	RemoteIp    string   `protobuf:"bytes,23,opt,name=remote_ip,json=remoteIp,proto3" json:"remote_ip,omitempty"`
+	UpstreamTls *TLSInfo `protobuf:"bytes,24,opt,name=upstream_tls,json=upstreamTls,proto3" json:"upstream_tls,omitempty"` // TLS negotiated with the upstream, if any
+	Gap         *Gap     `protobuf:"bytes,25,opt,name=gap,proto3" json:"gap,omitempty"`
+	// Position in the capture stream, increasing from 1. A flow updated on
+	// completion is sent again with a new seq.
+	Seq           uint64 `protobuf:"varint,26,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Flow) Reset() {
+	*x = Flow{}
+	mi := &file_apix_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Flow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flow) ProtoMessage() {}
+
+func (x *Flow) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flow.ProtoReflect.Descriptor instead.
+func (*Flow) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Flow) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Flow) GetRequest() *HttpRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *Flow) GetResponse() *HttpResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *Flow) GetStartedAtMs() int64 {
+	if x != nil {
+		return x.StartedAtMs
+	}
+	return 0
+}
+
+func (x *Flow) GetFinishedAtMs() int64 {
+	if x != nil {
+		return x.FinishedAtMs
+	}
+	return 0
+}
+
+func (x *Flow) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Flow) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Flow) GetClientAddr() string {
+	if x != nil {
+		return x.ClientAddr
+	}
+	return ""
+}
+
+func (x *Flow) GetTunnel() *Tunnel {
+	if x != nil {
+		return x.Tunnel
+	}
+	return nil
+}
+
+func (x *Flow) GetClientProtocol() string {
+	if x != nil {
+		return x.ClientProtocol
+	}
+	return ""
+}
+
+func (x *Flow) GetUpstreamProtocol() string {
+	if x != nil {
+		return x.UpstreamProtocol
+	}
+	return ""
+}
+
+func (x *Flow) GetClientStreamId() uint32 {
+	if x != nil {
+		return x.ClientStreamId
+	}
+	return 0
+}
+
+func (x *Flow) GetUpstreamStreamId() uint32 {
+	if x != nil {
+		return x.UpstreamStreamId
+	}
+	return 0
+}
+
+func (x *Flow) GetOpen() bool {
+	if x != nil {
+		return x.Open
+	}
+	return false
+}
+
+func (x *Flow) GetSseEvents() []*ServerSentEvent {
+	if x != nil {
+		return x.SseEvents
+	}
+	return nil
+}
+
+func (x *Flow) GetUpstreamRoute() string {
+	if x != nil {
+		return x.UpstreamRoute
+	}
+	return ""
+}
+
+func (x *Flow) GetTiming() *Timing {
+	if x != nil {
+		return x.Timing
+	}
+	return nil
+}
+
+func (x *Flow) GetProxyUser() string {
+	if x != nil {
+		return x.ProxyUser
+	}
+	return ""
+}
+
+func (x *Flow) GetNetworkProfile() string {
+	if x != nil {
+		return x.NetworkProfile
+	}
+	return ""
+}
+
+func (x *Flow) GetFault() *Fault {
+	if x != nil {
+		return x.Fault
+	}
+	return nil
+}
+
+func (x *Flow) GetLocalFile() string {
+	if x != nil {
+		return x.LocalFile
+	}
+	return ""
+}
+
+func (x *Flow) GetRemoteUrl() string {
+	if x != nil {
+		return x.RemoteUrl
+	}
+	return ""
+}
+
+func (x *Flow) GetRemoteIp() string {
+	if x != nil {
+		return x.RemoteIp
+	}
+	return ""
+}
+
+func (x *Flow) GetUpstreamTls() *TLSInfo {
+	if x != nil {
+		return x.UpstreamTls
+	}
+	return nil
+}
+
+func (x *Flow) GetGap() *Gap {
+	if x != nil {
+		return x.Gap
+	}
+	return nil
+}
+
+func (x *Flow) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// Gap marks where a subscriber that fell behind missed flows.
+type Gap struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dropped       int64                  `protobuf:"varint,1,opt,name=dropped,proto3" json:"dropped,omitempty"`                               // flows missed at this point of the stream
+	TotalDropped  int64                  `protobuf:"varint,2,opt,name=total_dropped,json=totalDropped,proto3" json:"total_dropped,omitempty"` // flows missed by the subscription so far
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Gap) Reset() {
+	*x = Gap{}
+	mi := &file_apix_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Gap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Gap) ProtoMessage() {}
+
+func (x *Gap) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Gap.ProtoReflect.Descriptor instead.
+func (*Gap) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Gap) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *Gap) GetTotalDropped() int64 {
+	if x != nil {
+		return x.TotalDropped
+	}
+	return 0
+}
+
+// TLSInfo describes a TLS connection.
+type TLSInfo struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Version            string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`                                                 // e.g. "TLS 1.3"
+	CipherSuite        string                 `protobuf:"bytes,2,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`                      // e.g. "TLS_AES_128_GCM_SHA256"
+	ServerName         string                 `protobuf:"bytes,3,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`                         // SNI sent
+	NegotiatedProtocol string                 `protobuf:"bytes,4,opt,name=negotiated_protocol,json=negotiatedProtocol,proto3" json:"negotiated_protocol,omitempty"` // ALPN result, e.g. "h2"
+	Resumed            bool                   `protobuf:"varint,5,opt,name=resumed,proto3" json:"resumed,omitempty"`
+	PeerCertificates   []*Certificate         `protobuf:"bytes,6,rep,name=peer_certificates,json=peerCertificates,proto3" json:"peer_certificates,omitempty"` // leaf first
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *TLSInfo) Reset() {
+	*x = TLSInfo{}
+	mi := &file_apix_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TLSInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TLSInfo) ProtoMessage() {}
+
+func (x *TLSInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TLSInfo.ProtoReflect.Descriptor instead.
+func (*TLSInfo) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TLSInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetCipherSuite() string {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetServerName() string {
+	if x != nil {
+		return x.ServerName
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetNegotiatedProtocol() string {
+	if x != nil {
+		return x.NegotiatedProtocol
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+func (x *TLSInfo) GetPeerCertificates() []*Certificate {
+	if x != nil {
+		return x.PeerCertificates
+	}
+	return nil
+}
+
+type Certificate struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Subject           string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Issuer            string                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	SerialNumber      string                 `protobuf:"bytes,3,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	NotBeforeMs       int64                  `protobuf:"varint,4,opt,name=not_before_ms,json=notBeforeMs,proto3" json:"not_before_ms,omitempty"` // unix milliseconds
+	NotAfterMs        int64                  `protobuf:"varint,5,opt,name=not_after_ms,json=notAfterMs,proto3" json:"not_after_ms,omitempty"`    // unix milliseconds
+	DnsNames          []string               `protobuf:"bytes,6,rep,name=dns_names,json=dnsNames,proto3" json:"dns_names,omitempty"`
+	Sha256Fingerprint string                 `protobuf:"bytes,7,opt,name=sha256_fingerprint,json=sha256Fingerprint,proto3" json:"sha256_fingerprint,omitempty"`
+	Der               []byte                 `protobuf:"bytes,8,opt,name=der,proto3" json:"der,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	mi := &file_apix_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Certificate) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Certificate) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *Certificate) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *Certificate) GetNotBeforeMs() int64 {
+	if x != nil {
+		return x.NotBeforeMs
+	}
+	return 0
+}
+
+func (x *Certificate) GetNotAfterMs() int64 {
+	if x != nil {
+		return x.NotAfterMs
+	}
+	return 0
+}
+
+func (x *Certificate) GetDnsNames() []string {
+	if x != nil {
+		return x.DnsNames
+	}
+	return nil
+}
+
+func (x *Certificate) GetSha256Fingerprint() string {
+	if x != nil {
+		return x.Sha256Fingerprint
+	}
+	return ""
+}
+
+func (x *Certificate) GetDer() []byte {
+	if x != nil {
+		return x.Der
+	}
+	return nil
+}
+
+// A failure injected into a flow by a fault rule.
+type Fault struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`     // status, reset, hang, truncate or malformed
+	Rule          string                 `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`     // URL pattern of the rule that fired
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"` // e.g. "status 503" or "truncate at 1024 bytes"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fault) Reset() {
+	*x = Fault{}
+	mi := &file_apix_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fault) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fault) ProtoMessage() {}
+
+func (x *Fault) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fault.ProtoReflect.Descriptor instead.
+func (*Fault) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Fault) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Fault) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Fault) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// A simulated network. Zero values leave that aspect untouched.
+type NetworkProfile struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DownloadKbps     int64                  `protobuf:"varint,2,opt,name=download_kbps,json=downloadKbps,proto3" json:"download_kbps,omitempty"`
+	UploadKbps       int64                  `protobuf:"varint,3,opt,name=upload_kbps,json=uploadKbps,proto3" json:"upload_kbps,omitempty"`
+	LatencyMs        int64                  `protobuf:"varint,4,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	JitterMs         int64                  `protobuf:"varint,5,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`
+	ResetProbability float64                `protobuf:"fixed64,6,opt,name=reset_probability,json=resetProbability,proto3" json:"reset_probability,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *NetworkProfile) Reset() {
+	*x = NetworkProfile{}
+	mi := &file_apix_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkProfile) ProtoMessage() {}
+
+func (x *NetworkProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkProfile.ProtoReflect.Descriptor instead.
+func (*NetworkProfile) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *NetworkProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NetworkProfile) GetDownloadKbps() int64 {
+	if x != nil {
+		return x.DownloadKbps
+	}
+	return 0
+}
+
+func (x *NetworkProfile) GetUploadKbps() int64 {
+	if x != nil {
+		return x.UploadKbps
+	}
+	return 0
+}
+
+func (x *NetworkProfile) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *NetworkProfile) GetJitterMs() int64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+func (x *NetworkProfile) GetResetProbability() float64 {
+	if x != nil {
+		return x.ResetProbability
+	}
+	return 0
+}
+
+// Applies a profile to hosts matching a glob pattern or host:port; an
+// empty host applies it globally.
+type NetworkProfileAssignment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkProfileAssignment) Reset() {
+	*x = NetworkProfileAssignment{}
+	mi := &file_apix_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkProfileAssignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkProfileAssignment) ProtoMessage() {}
+
+func (x *NetworkProfileAssignment) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkProfileAssignment.ProtoReflect.Descriptor instead.
+func (*NetworkProfileAssignment) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *NetworkProfileAssignment) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *NetworkProfileAssignment) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+// Sends connections for host, a name or a pattern such as "*.example.com",
+// to target, an IP address or another host name.
+type HostOverride struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostOverride) Reset() {
+	*x = HostOverride{}
+	mi := &file_apix_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostOverride) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostOverride) ProtoMessage() {}
+
+func (x *HostOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostOverride.ProtoReflect.Descriptor instead.
+func (*HostOverride) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *HostOverride) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HostOverride) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+// Timing breaks the upstream leg of a flow into consecutive phases, in
+// microseconds. Phases that did not happen, such as DNS and connect on a
+// reused connection, are zero.
+type Timing struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DnsUs            int64                  `protobuf:"varint,1,opt,name=dns_us,json=dnsUs,proto3" json:"dns_us,omitempty"`
+	ConnectUs        int64                  `protobuf:"varint,2,opt,name=connect_us,json=connectUs,proto3" json:"connect_us,omitempty"`
+	TlsUs            int64                  `protobuf:"varint,3,opt,name=tls_us,json=tlsUs,proto3" json:"tls_us,omitempty"`
+	RequestWriteUs   int64                  `protobuf:"varint,4,opt,name=request_write_us,json=requestWriteUs,proto3" json:"request_write_us,omitempty"` // from getting a connection until the request is sent
+	TtfbUs           int64                  `protobuf:"varint,5,opt,name=ttfb_us,json=ttfbUs,proto3" json:"ttfb_us,omitempty"`                           // from the request being sent until the first response byte
+	TransferUs       int64                  `protobuf:"varint,6,opt,name=transfer_us,json=transferUs,proto3" json:"transfer_us,omitempty"`               // from the first response byte until the body is done
+	ConnectionReused bool                   `protobuf:"varint,7,opt,name=connection_reused,json=connectionReused,proto3" json:"connection_reused,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Timing) Reset() {
+	*x = Timing{}
+	mi := &file_apix_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Timing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Timing) ProtoMessage() {}
+
+func (x *Timing) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Timing.ProtoReflect.Descriptor instead.
+func (*Timing) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Timing) GetDnsUs() int64 {
+	if x != nil {
+		return x.DnsUs
+	}
+	return 0
+}
+
+func (x *Timing) GetConnectUs() int64 {
+	if x != nil {
+		return x.ConnectUs
+	}
+	return 0
+}
+
+func (x *Timing) GetTlsUs() int64 {
+	if x != nil {
+		return x.TlsUs
+	}
+	return 0
+}
+
+func (x *Timing) GetRequestWriteUs() int64 {
+	if x != nil {
+		return x.RequestWriteUs
+	}
+	return 0
+}
+
+func (x *Timing) GetTtfbUs() int64 {
+	if x != nil {
+		return x.TtfbUs
+	}
+	return 0
+}
+
+func (x *Timing) GetTransferUs() int64 {
+	if x != nil {
+		return x.TransferUs
+	}
+	return 0
+}
+
+func (x *Timing) GetConnectionReused() bool {
+	if x != nil {
+		return x.ConnectionReused
+	}
+	return false
+}
+
+// A single event of a text/event-stream response
+type ServerSentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FlowId        string                 `protobuf:"bytes,1,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Event         string                 `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	Data          string                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Retry         int64                  `protobuf:"varint,5,opt,name=retry,proto3" json:"retry,omitempty"`
+	TimestampMs   int64                  `protobuf:"varint,6,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"` // unix milliseconds
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerSentEvent) Reset() {
+	*x = ServerSentEvent{}
+	mi := &file_apix_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerSentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerSentEvent) ProtoMessage() {}
+
+func (x *ServerSentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerSentEvent.ProtoReflect.Descriptor instead.
+func (*ServerSentEvent) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ServerSentEvent) GetFlowId() string {
+	if x != nil {
+		return x.FlowId
+	}
+	return ""
+}
+
+func (x *ServerSentEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ServerSentEvent) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *ServerSentEvent) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ServerSentEvent) GetRetry() int64 {
+	if x != nil {
+		return x.Retry
+	}
+	return 0
+}
+
+func (x *ServerSentEvent) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+// A single WebSocket frame relayed on an upgraded flow
+type WebSocketFrame struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	FlowId           string                 `protobuf:"bytes,1,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	Direction        FrameDirection         `protobuf:"varint,2,opt,name=direction,proto3,enum=apix.FrameDirection" json:"direction,omitempty"`
+	Opcode           int32                  `protobuf:"varint,3,opt,name=opcode,proto3" json:"opcode,omitempty"` // 0 continuation, 1 text, 2 binary, 8 close, 9 ping, 10 pong
+	Fin              bool                   `protobuf:"varint,4,opt,name=fin,proto3" json:"fin,omitempty"`
+	Payload          []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`                             // unmasked payload
+	PayloadSize      int64                  `protobuf:"varint,6,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"` // full payload length, even past the capture cap
+	PayloadTruncated bool                   `protobuf:"varint,7,opt,name=payload_truncated,json=payloadTruncated,proto3" json:"payload_truncated,omitempty"`
+	TimestampMs      int64                  `protobuf:"varint,8,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"` // unix milliseconds
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WebSocketFrame) Reset() {
+	*x = WebSocketFrame{}
+	mi := &file_apix_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebSocketFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebSocketFrame) ProtoMessage() {}
+
+func (x *WebSocketFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebSocketFrame.ProtoReflect.Descriptor instead.
+func (*WebSocketFrame) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WebSocketFrame) GetFlowId() string {
+	if x != nil {
+		return x.FlowId
+	}
+	return ""
+}
+
+func (x *WebSocketFrame) GetDirection() FrameDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return FrameDirection_CLIENT_TO_SERVER
+}
+
+func (x *WebSocketFrame) GetOpcode() int32 {
+	if x != nil {
+		return x.Opcode
+	}
+	return 0
+}
+
+func (x *WebSocketFrame) GetFin() bool {
+	if x != nil {
+		return x.Fin
+	}
+	return false
+}
+
+func (x *WebSocketFrame) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *WebSocketFrame) GetPayloadSize() int64 {
+	if x != nil {
+		return x.PayloadSize
+	}
+	return 0
+}
+
+func (x *WebSocketFrame) GetPayloadTruncated() bool {
+	if x != nil {
+		return x.PayloadTruncated
+	}
+	return false
+}
+
+func (x *WebSocketFrame) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+// Plugins info
+type PluginInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginInfo) Reset() {
+	*x = PluginInfo{}
+	mi := &file_apix_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginInfo) ProtoMessage() {}
+
+func (x *PluginInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginInfo.ProtoReflect.Descriptor instead.
+func (*PluginInfo) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PluginInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// Request message for status RPC
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_apix_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{16}
+}
+
+// Request message for CaptureTraffic RPC. Unset fields take the engine's
+// defaults.
+type CaptureRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// What to do once buffer_size flows are waiting to be sent: "drop-oldest",
+	// "drop-newest", "block" or "disconnect"
+	Policy         string `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	BufferSize     int32  `protobuf:"varint,2,opt,name=buffer_size,json=bufferSize,proto3" json:"buffer_size,omitempty"`
+	BlockTimeoutMs int64  `protobuf:"varint,3,opt,name=block_timeout_ms,json=blockTimeoutMs,proto3" json:"block_timeout_ms,omitempty"` // how long the block policy holds up capture
+	// Stored flows to send before live ones, at most one of: those from a
+	// sequence number on, e.g. one past the last seen to resume a stream, the
+	// latest last ones, or those started at or after since_ms (unix
+	// milliseconds). Flows from from_seq on that were already evicted are
+	// reported as a gap.
+	FromSeq uint64 `protobuf:"varint,4,opt,name=from_seq,json=fromSeq,proto3" json:"from_seq,omitempty"`
+	Last    int32  `protobuf:"varint,5,opt,name=last,proto3" json:"last,omitempty"`
+	SinceMs int64  `protobuf:"varint,6,opt,name=since_ms,json=sinceMs,proto3" json:"since_ms,omitempty"`
+	// Only send flows matching an expression, e.g.
+	// host ~ "api\\.example" && method == "POST" && status >= 400
+	Filter        string `protobuf:"bytes,7,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CaptureRequest) Reset() {
+	*x = CaptureRequest{}
+	mi := &file_apix_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaptureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureRequest) ProtoMessage() {}
+
+func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureRequest.ProtoReflect.Descriptor instead.
+func (*CaptureRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CaptureRequest) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetBufferSize() int32 {
+	if x != nil {
+		return x.BufferSize
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetBlockTimeoutMs() int64 {
+	if x != nil {
+		return x.BlockTimeoutMs
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetFromSeq() uint64 {
+	if x != nil {
+		return x.FromSeq
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetLast() int32 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetSinceMs() int64 {
+	if x != nil {
+		return x.SinceMs
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+// Request message for CaptureWebSocket RPC, optionally limited to one flow
+type WebSocketCaptureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FlowId        string                 `protobuf:"bytes,1,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebSocketCaptureRequest) Reset() {
+	*x = WebSocketCaptureRequest{}
+	mi := &file_apix_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebSocketCaptureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebSocketCaptureRequest) ProtoMessage() {}
+
+func (x *WebSocketCaptureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebSocketCaptureRequest.ProtoReflect.Descriptor instead.
+func (*WebSocketCaptureRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WebSocketCaptureRequest) GetFlowId() string {
+	if x != nil {
+		return x.FlowId
+	}
+	return ""
+}
+
+// Request message for CaptureServerSentEvents RPC, optionally limited to one flow
+type ServerSentEventCaptureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FlowId        string                 `protobuf:"bytes,1,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerSentEventCaptureRequest) Reset() {
+	*x = ServerSentEventCaptureRequest{}
+	mi := &file_apix_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerSentEventCaptureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerSentEventCaptureRequest) ProtoMessage() {}
+
+func (x *ServerSentEventCaptureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerSentEventCaptureRequest.ProtoReflect.Descriptor instead.
+func (*ServerSentEventCaptureRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ServerSentEventCaptureRequest) GetFlowId() string {
+	if x != nil {
+		return x.FlowId
+	}
+	return ""
+}
+
+// New empty message for ListPlugins request
+type PluginListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginListRequest) Reset() {
+	*x = PluginListRequest{}
+	mi := &file_apix_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginListRequest) ProtoMessage() {}
+
+func (x *PluginListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginListRequest.ProtoReflect.Descriptor instead.
+func (*PluginListRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{20}
+}
+
+// Request message for GetNetworkProfiles RPC
+type NetworkProfilesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkProfilesRequest) Reset() {
+	*x = NetworkProfilesRequest{}
+	mi := &file_apix_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkProfilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkProfilesRequest) ProtoMessage() {}
+
+func (x *NetworkProfilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkProfilesRequest.ProtoReflect.Descriptor instead.
+func (*NetworkProfilesRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{21}
+}
+
+// Request message for SetNetworkProfile RPC. An empty profile removes the
+// assignment for host.
+type SetNetworkProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNetworkProfileRequest) Reset() {
+	*x = SetNetworkProfileRequest{}
+	mi := &file_apix_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNetworkProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNetworkProfileRequest) ProtoMessage() {}
+
+func (x *SetNetworkProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNetworkProfileRequest.ProtoReflect.Descriptor instead.
+func (*SetNetworkProfileRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SetNetworkProfileRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *SetNetworkProfileRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+// Request message for GetHostOverrides RPC
+type HostOverridesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostOverridesRequest) Reset() {
+	*x = HostOverridesRequest{}
+	mi := &file_apix_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostOverridesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostOverridesRequest) ProtoMessage() {}
+
+func (x *HostOverridesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostOverridesRequest.ProtoReflect.Descriptor instead.
+func (*HostOverridesRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{23}
+}
+
+// Request message for ListFlows RPC
+type ListFlowsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter string                 `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"` // expression as for CaptureRequest.filter
+	// Field of the filter language to sort by, descending when prefixed with
+	// "-", e.g. "-duration"; stream order (seq) by default
+	Sort          string `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	PageSize      int32  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // 100 by default, at most 1000
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlowsRequest) Reset() {
+	*x = ListFlowsRequest{}
+	mi := &file_apix_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlowsRequest) ProtoMessage() {}
+
+func (x *ListFlowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlowsRequest.ProtoReflect.Descriptor instead.
+func (*ListFlowsRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListFlowsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *ListFlowsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListFlowsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListFlowsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Request message for SearchFlows RPC
+type SearchFlowsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"` // text to find, case-insensitively, in URLs, headers and bodies
+	Filter        string                 `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchFlowsRequest) Reset() {
+	*x = SearchFlowsRequest{}
+	mi := &file_apix_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchFlowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchFlowsRequest) ProtoMessage() {}
+
+func (x *SearchFlowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchFlowsRequest.ProtoReflect.Descriptor instead.
+func (*SearchFlowsRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SearchFlowsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchFlowsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *SearchFlowsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *SearchFlowsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchFlowsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Request message for GetFlow RPC
+type GetFlowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFlowRequest) Reset() {
+	*x = GetFlowRequest{}
+	mi := &file_apix_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFlowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFlowRequest) ProtoMessage() {}
+
+func (x *GetFlowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFlowRequest.ProtoReflect.Descriptor instead.
+func (*GetFlowRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetFlowRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Request message for DeleteFlows RPC. Deletes the flows with the listed
+// IDs and, if a filter is set, those matching it.
+type DeleteFlowsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	Filter        string                 `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFlowsRequest) Reset() {
+	*x = DeleteFlowsRequest{}
+	mi := &file_apix_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFlowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFlowsRequest) ProtoMessage() {}
+
+func (x *DeleteFlowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFlowsRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFlowsRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DeleteFlowsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *DeleteFlowsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+// Request message for ClearFlows RPC
+type ClearFlowsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearFlowsRequest) Reset() {
+	*x = ClearFlowsRequest{}
+	mi := &file_apix_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearFlowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearFlowsRequest) ProtoMessage() {}
+
+func (x *ClearFlowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearFlowsRequest.ProtoReflect.Descriptor instead.
+func (*ClearFlowsRequest) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{28}
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Retention     *RetentionStats        `protobuf:"bytes,3,opt,name=retention,proto3" json:"retention,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HttpRequest) Reset() {
-	*x = HttpRequest{}
-	mi := &file_apix_proto_msgTypes[0]
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_apix_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HttpRequest) String() string {
+func (x *StatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HttpRequest) ProtoMessage() {}
+func (*StatusResponse) ProtoMessage() {}
 
-func (x *HttpRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[0]
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -58,71 +2224,65 @@ func (x *HttpRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HttpRequest.ProtoReflect.Descriptor instead.
-func (*HttpRequest) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *HttpRequest) GetMethod() string {
+func (x *StatusResponse) GetStatus() string {
 	if x != nil {
-		return x.Method
+		return x.Status
 	}
 	return ""
 }
 
-func (x *HttpRequest) GetUrl() string {
+func (x *StatusResponse) GetVersion() string {
 	if x != nil {
-		return x.Url
+		return x.Version
 	}
 	return ""
 }
 
-func (x *HttpRequest) GetHeaders() map[string]string {
+func (x *StatusResponse) GetRetention() *RetentionStats {
 	if x != nil {
-		return x.Headers
+		return x.Retention
 	}
 	return nil
 }
 
-func (x *HttpRequest) GetBody() string {
-	if x != nil {
-		return x.Body
-	}
-	return ""
-}
-
-func (x *HttpRequest) GetTimestamp() int64 {
-	if x != nil {
-		return x.Timestamp
-	}
-	return 0
-}
-
-// A single HTTP response captured by the proxy
-type HttpResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	StatusCode    int32                  `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
-	Headers       map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Size of the capture history, its limits (zero when disabled) and what
+// was evicted to stay within them.
+type RetentionStats struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Flows          int64                  `protobuf:"varint,1,opt,name=flows,proto3" json:"flows,omitempty"`
+	Bytes          int64                  `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	MaxFlows       int64                  `protobuf:"varint,3,opt,name=max_flows,json=maxFlows,proto3" json:"max_flows,omitempty"`
+	MaxBytes       int64                  `protobuf:"varint,4,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	MaxAgeSeconds  int64                  `protobuf:"varint,5,opt,name=max_age_seconds,json=maxAgeSeconds,proto3" json:"max_age_seconds,omitempty"`
+	EvictedFlows   int64                  `protobuf:"varint,6,opt,name=evicted_flows,json=evictedFlows,proto3" json:"evicted_flows,omitempty"`
+	EvictedBytes   int64                  `protobuf:"varint,7,opt,name=evicted_bytes,json=evictedBytes,proto3" json:"evicted_bytes,omitempty"`
+	EvictedByCount int64                  `protobuf:"varint,8,opt,name=evicted_by_count,json=evictedByCount,proto3" json:"evicted_by_count,omitempty"`
+	EvictedBySize  int64                  `protobuf:"varint,9,opt,name=evicted_by_size,json=evictedBySize,proto3" json:"evicted_by_size,omitempty"`
+	EvictedByAge   int64                  `protobuf:"varint,10,opt,name=evicted_by_age,json=evictedByAge,proto3" json:"evicted_by_age,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *HttpResponse) Reset() {
-	*x = HttpResponse{}
-	mi := &file_apix_proto_msgTypes[1]
+func (x *RetentionStats) Reset() {
+	*x = RetentionStats{}
+	mi := &file_apix_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HttpResponse) String() string {
+func (x *RetentionStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HttpResponse) ProtoMessage() {}
+func (*RetentionStats) ProtoMessage() {}
 
-func (x *HttpResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[1]
+func (x *RetentionStats) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -133,115 +2293,103 @@ func (x *HttpResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HttpResponse.ProtoReflect.Descriptor instead.
-func (*HttpResponse) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use RetentionStats.ProtoReflect.Descriptor instead.
+func (*RetentionStats) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *HttpResponse) GetStatusCode() int32 {
+func (x *RetentionStats) GetFlows() int64 {
 	if x != nil {
-		return x.StatusCode
+		return x.Flows
 	}
 	return 0
 }
 
-func (x *HttpResponse) GetHeaders() map[string]string {
+func (x *RetentionStats) GetBytes() int64 {
 	if x != nil {
-		return x.Headers
+		return x.Bytes
 	}
-	return nil
+	return 0
 }
 
-func (x *HttpResponse) GetBody() string {
+func (x *RetentionStats) GetMaxFlows() int64 {
 	if x != nil {
-		return x.Body
+		return x.MaxFlows
 	}
-	return ""
-}
-
-// Plugins info
-type PluginInfo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	return 0
 }
 
-func (x *PluginInfo) Reset() {
-	*x = PluginInfo{}
-	mi := &file_apix_proto_msgTypes[2]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *RetentionStats) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
 }
 
-func (x *PluginInfo) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *RetentionStats) GetMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.MaxAgeSeconds
+	}
+	return 0
 }
 
-func (*PluginInfo) ProtoMessage() {}
-
-func (x *PluginInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[2]
+func (x *RetentionStats) GetEvictedFlows() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.EvictedFlows
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use PluginInfo.ProtoReflect.Descriptor instead.
-func (*PluginInfo) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{2}
+func (x *RetentionStats) GetEvictedBytes() int64 {
+	if x != nil {
+		return x.EvictedBytes
+	}
+	return 0
 }
 
-func (x *PluginInfo) GetName() string {
+func (x *RetentionStats) GetEvictedByCount() int64 {
 	if x != nil {
-		return x.Name
+		return x.EvictedByCount
 	}
-	return ""
+	return 0
 }
 
-func (x *PluginInfo) GetVersion() string {
+func (x *RetentionStats) GetEvictedBySize() int64 {
 	if x != nil {
-		return x.Version
+		return x.EvictedBySize
 	}
-	return ""
+	return 0
 }
 
-func (x *PluginInfo) GetDescription() string {
+func (x *RetentionStats) GetEvictedByAge() int64 {
 	if x != nil {
-		return x.Description
+		return x.EvictedByAge
 	}
-	return ""
+	return 0
 }
 
-// Request message for status RPC
-type StatusRequest struct {
+type PluginListResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plugins       []*PluginInfo          `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatusRequest) Reset() {
-	*x = StatusRequest{}
-	mi := &file_apix_proto_msgTypes[3]
+func (x *PluginListResponse) Reset() {
+	*x = PluginListResponse{}
+	mi := &file_apix_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusRequest) String() string {
+func (x *PluginListResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusRequest) ProtoMessage() {}
+func (*PluginListResponse) ProtoMessage() {}
 
-func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[3]
+func (x *PluginListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -252,33 +2400,41 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
-func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use PluginListResponse.ProtoReflect.Descriptor instead.
+func (*PluginListResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{31}
 }
 
-// New empty message for CaptureTraffic RPC
-type CaptureRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *PluginListResponse) GetPlugins() []*PluginInfo {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+type NetworkProfilesResponse struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Profiles      []*NetworkProfile           `protobuf:"bytes,1,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	Assignments   []*NetworkProfileAssignment `protobuf:"bytes,2,rep,name=assignments,proto3" json:"assignments,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CaptureRequest) Reset() {
-	*x = CaptureRequest{}
-	mi := &file_apix_proto_msgTypes[4]
+func (x *NetworkProfilesResponse) Reset() {
+	*x = NetworkProfilesResponse{}
+	mi := &file_apix_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CaptureRequest) String() string {
+func (x *NetworkProfilesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CaptureRequest) ProtoMessage() {}
+func (*NetworkProfilesResponse) ProtoMessage() {}
 
-func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[4]
+func (x *NetworkProfilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -289,33 +2445,48 @@ func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CaptureRequest.ProtoReflect.Descriptor instead.
-func (*CaptureRequest) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use NetworkProfilesResponse.ProtoReflect.Descriptor instead.
+func (*NetworkProfilesResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{32}
 }
 
-// New empty message for ListPlugins request
-type PluginListRequest struct {
+func (x *NetworkProfilesResponse) GetProfiles() []*NetworkProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+func (x *NetworkProfilesResponse) GetAssignments() []*NetworkProfileAssignment {
+	if x != nil {
+		return x.Assignments
+	}
+	return nil
+}
+
+type HostOverridesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Overrides     []*HostOverride        `protobuf:"bytes,1,rep,name=overrides,proto3" json:"overrides,omitempty"`
+	Resolver      string                 `protobuf:"bytes,2,opt,name=resolver,proto3" json:"resolver,omitempty"` // where other names are resolved: "system", DNS servers or a DoH URL
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PluginListRequest) Reset() {
-	*x = PluginListRequest{}
-	mi := &file_apix_proto_msgTypes[5]
+func (x *HostOverridesResponse) Reset() {
+	*x = HostOverridesResponse{}
+	mi := &file_apix_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PluginListRequest) String() string {
+func (x *HostOverridesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PluginListRequest) ProtoMessage() {}
+func (*HostOverridesResponse) ProtoMessage() {}
 
-func (x *PluginListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[5]
+func (x *HostOverridesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -326,34 +2497,49 @@ func (x *PluginListRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PluginListRequest.ProtoReflect.Descriptor instead.
-func (*PluginListRequest) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use HostOverridesResponse.ProtoReflect.Descriptor instead.
+func (*HostOverridesResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{33}
 }
 
-type StatusResponse struct {
+func (x *HostOverridesResponse) GetOverrides() []*HostOverride {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+func (x *HostOverridesResponse) GetResolver() string {
+	if x != nil {
+		return x.Resolver
+	}
+	return ""
+}
+
+type ListFlowsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Flows         []*Flow                `protobuf:"bytes,1,rep,name=flows,proto3" json:"flows,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	Total         int64                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`                                       // flows on all pages
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatusResponse) Reset() {
-	*x = StatusResponse{}
-	mi := &file_apix_proto_msgTypes[6]
+func (x *ListFlowsResponse) Reset() {
+	*x = ListFlowsResponse{}
+	mi := &file_apix_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusResponse) String() string {
+func (x *ListFlowsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusResponse) ProtoMessage() {}
+func (*ListFlowsResponse) ProtoMessage() {}
 
-func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[6]
+func (x *ListFlowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -364,47 +2550,54 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
-func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ListFlowsResponse.ProtoReflect.Descriptor instead.
+func (*ListFlowsResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *StatusResponse) GetStatus() string {
+func (x *ListFlowsResponse) GetFlows() []*Flow {
 	if x != nil {
-		return x.Status
+		return x.Flows
 	}
-	return ""
+	return nil
 }
 
-func (x *StatusResponse) GetVersion() string {
+func (x *ListFlowsResponse) GetNextPageToken() string {
 	if x != nil {
-		return x.Version
+		return x.NextPageToken
 	}
 	return ""
 }
 
-type PluginListResponse struct {
+func (x *ListFlowsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type DeleteFlowsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Plugins       []*PluginInfo          `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Deleted       int64                  `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PluginListResponse) Reset() {
-	*x = PluginListResponse{}
-	mi := &file_apix_proto_msgTypes[7]
+func (x *DeleteFlowsResponse) Reset() {
+	*x = DeleteFlowsResponse{}
+	mi := &file_apix_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PluginListResponse) String() string {
+func (x *DeleteFlowsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PluginListResponse) ProtoMessage() {}
+func (*DeleteFlowsResponse) ProtoMessage() {}
 
-func (x *PluginListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_apix_proto_msgTypes[7]
+func (x *DeleteFlowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apix_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -415,16 +2608,16 @@ func (x *PluginListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PluginListResponse.ProtoReflect.Descriptor instead.
-func (*PluginListResponse) Descriptor() ([]byte, []int) {
-	return file_apix_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use DeleteFlowsResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFlowsResponse) Descriptor() ([]byte, []int) {
+	return file_apix_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *PluginListResponse) GetPlugins() []*PluginInfo {
+func (x *DeleteFlowsResponse) GetDeleted() int64 {
 	if x != nil {
-		return x.Plugins
+		return x.Deleted
 	}
-	return nil
+	return 0
 }
 
 var File_apix_proto protoreflect.FileDescriptor
@@ -432,41 +2625,244 @@ var File_apix_proto protoreflect.FileDescriptor
 const file_apix_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"apix.proto\x12\x04apix\"\xdf\x01\n" +
+	"apix.proto\x12\x04apix\"2\n" +
+	"\x06Header\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"\x99\x03\n" +
 	"\vHttpRequest\x12\x16\n" +
 	"\x06method\x18\x01 \x01(\tR\x06method\x12\x10\n" +
 	"\x03url\x18\x02 \x01(\tR\x03url\x128\n" +
 	"\aheaders\x18\x03 \x03(\v2\x1e.apix.HttpRequest.HeadersEntryR\aheaders\x12\x12\n" +
-	"\x04body\x18\x04 \x01(\tR\x04body\x12\x1c\n" +
-	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x1a:\n" +
+	"\x04body\x18\x04 \x01(\fR\x04body\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\tbody_size\x18\x06 \x01(\x03R\bbodySize\x12%\n" +
+	"\x0ebody_truncated\x18\a \x01(\bR\rbodyTruncated\x12\x1b\n" +
+	"\tbody_file\x18\b \x01(\tR\bbodyFile\x12-\n" +
+	"\vheader_list\x18\t \x03(\v2\f.apix.HeaderR\n" +
+	"headerList\x12(\n" +
+	"\btrailers\x18\n" +
+	" \x03(\v2\f.apix.HeaderR\btrailers\x1a:\n" +
 	"\fHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xba\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf4\x02\n" +
 	"\fHttpResponse\x12\x1f\n" +
 	"\vstatus_code\x18\x01 \x01(\x05R\n" +
 	"statusCode\x129\n" +
 	"\aheaders\x18\x02 \x03(\v2\x1f.apix.HttpResponse.HeadersEntryR\aheaders\x12\x12\n" +
-	"\x04body\x18\x03 \x01(\tR\x04body\x1a:\n" +
+	"\x04body\x18\x03 \x01(\fR\x04body\x12\x1b\n" +
+	"\tbody_size\x18\x04 \x01(\x03R\bbodySize\x12%\n" +
+	"\x0ebody_truncated\x18\x05 \x01(\bR\rbodyTruncated\x12\x1b\n" +
+	"\tbody_file\x18\x06 \x01(\tR\bbodyFile\x12-\n" +
+	"\vheader_list\x18\a \x03(\v2\f.apix.HeaderR\n" +
+	"headerList\x12(\n" +
+	"\btrailers\x18\b \x03(\v2\f.apix.HeaderR\btrailers\x1a:\n" +
 	"\fHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\\\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xaf\x01\n" +
+	"\x06Tunnel\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x19\n" +
+	"\bbytes_in\x18\x02 \x01(\x03R\abytesIn\x12\x1b\n" +
+	"\tbytes_out\x18\x03 \x01(\x03R\bbytesOut\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x1a\n" +
+	"\bprotocol\x18\x06 \x01(\tR\bprotocol\"\xa7\a\n" +
+	"\x04Flow\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12+\n" +
+	"\arequest\x18\x02 \x01(\v2\x11.apix.HttpRequestR\arequest\x12.\n" +
+	"\bresponse\x18\x03 \x01(\v2\x12.apix.HttpResponseR\bresponse\x12\"\n" +
+	"\rstarted_at_ms\x18\x04 \x01(\x03R\vstartedAtMs\x12$\n" +
+	"\x0efinished_at_ms\x18\x05 \x01(\x03R\ffinishedAtMs\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x03R\n" +
+	"durationMs\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x1f\n" +
+	"\vclient_addr\x18\b \x01(\tR\n" +
+	"clientAddr\x12$\n" +
+	"\x06tunnel\x18\t \x01(\v2\f.apix.TunnelR\x06tunnel\x12'\n" +
+	"\x0fclient_protocol\x18\n" +
+	" \x01(\tR\x0eclientProtocol\x12+\n" +
+	"\x11upstream_protocol\x18\v \x01(\tR\x10upstreamProtocol\x12(\n" +
+	"\x10client_stream_id\x18\f \x01(\rR\x0eclientStreamId\x12,\n" +
+	"\x12upstream_stream_id\x18\r \x01(\rR\x10upstreamStreamId\x12\x12\n" +
+	"\x04open\x18\x0e \x01(\bR\x04open\x124\n" +
+	"\n" +
+	"sse_events\x18\x0f \x03(\v2\x15.apix.ServerSentEventR\tsseEvents\x12%\n" +
+	"\x0eupstream_route\x18\x10 \x01(\tR\rupstreamRoute\x12$\n" +
+	"\x06timing\x18\x11 \x01(\v2\f.apix.TimingR\x06timing\x12\x1d\n" +
+	"\n" +
+	"proxy_user\x18\x12 \x01(\tR\tproxyUser\x12'\n" +
+	"\x0fnetwork_profile\x18\x13 \x01(\tR\x0enetworkProfile\x12!\n" +
+	"\x05fault\x18\x14 \x01(\v2\v.apix.FaultR\x05fault\x12\x1d\n" +
+	"\n" +
+	"local_file\x18\x15 \x01(\tR\tlocalFile\x12\x1d\n" +
+	"\n" +
+	"remote_url\x18\x16 \x01(\tR\tremoteUrl\x12\x1b\n" +
+	"\tremote_ip\x18\x17 \x01(\tR\bremoteIp\x120\n" +
+	"\fupstream_tls\x18\x18 \x01(\v2\r.apix.TLSInfoR\vupstreamTls\x12\x1b\n" +
+	"\x03gap\x18\x19 \x01(\v2\t.apix.GapR\x03gap\x12\x10\n" +
+	"\x03seq\x18\x1a \x01(\x04R\x03seq\"D\n" +
+	"\x03Gap\x12\x18\n" +
+	"\adropped\x18\x01 \x01(\x03R\adropped\x12#\n" +
+	"\rtotal_dropped\x18\x02 \x01(\x03R\ftotalDropped\"\xf2\x01\n" +
+	"\aTLSInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12!\n" +
+	"\fcipher_suite\x18\x02 \x01(\tR\vcipherSuite\x12\x1f\n" +
+	"\vserver_name\x18\x03 \x01(\tR\n" +
+	"serverName\x12/\n" +
+	"\x13negotiated_protocol\x18\x04 \x01(\tR\x12negotiatedProtocol\x12\x18\n" +
+	"\aresumed\x18\x05 \x01(\bR\aresumed\x12>\n" +
+	"\x11peer_certificates\x18\x06 \x03(\v2\x11.apix.CertificateR\x10peerCertificates\"\x88\x02\n" +
+	"\vCertificate\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\tR\x06issuer\x12#\n" +
+	"\rserial_number\x18\x03 \x01(\tR\fserialNumber\x12\"\n" +
+	"\rnot_before_ms\x18\x04 \x01(\x03R\vnotBeforeMs\x12 \n" +
+	"\fnot_after_ms\x18\x05 \x01(\x03R\n" +
+	"notAfterMs\x12\x1b\n" +
+	"\tdns_names\x18\x06 \x03(\tR\bdnsNames\x12-\n" +
+	"\x12sha256_fingerprint\x18\a \x01(\tR\x11sha256Fingerprint\x12\x10\n" +
+	"\x03der\x18\b \x01(\fR\x03der\"G\n" +
+	"\x05Fault\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04rule\x18\x02 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xd3\x01\n" +
+	"\x0eNetworkProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rdownload_kbps\x18\x02 \x01(\x03R\fdownloadKbps\x12\x1f\n" +
+	"\vupload_kbps\x18\x03 \x01(\x03R\n" +
+	"uploadKbps\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x04 \x01(\x03R\tlatencyMs\x12\x1b\n" +
+	"\tjitter_ms\x18\x05 \x01(\x03R\bjitterMs\x12+\n" +
+	"\x11reset_probability\x18\x06 \x01(\x01R\x10resetProbability\"H\n" +
+	"\x18NetworkProfileAssignment\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\":\n" +
+	"\fHostOverride\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\"\xe6\x01\n" +
+	"\x06Timing\x12\x15\n" +
+	"\x06dns_us\x18\x01 \x01(\x03R\x05dnsUs\x12\x1d\n" +
+	"\n" +
+	"connect_us\x18\x02 \x01(\x03R\tconnectUs\x12\x15\n" +
+	"\x06tls_us\x18\x03 \x01(\x03R\x05tlsUs\x12(\n" +
+	"\x10request_write_us\x18\x04 \x01(\x03R\x0erequestWriteUs\x12\x17\n" +
+	"\attfb_us\x18\x05 \x01(\x03R\x06ttfbUs\x12\x1f\n" +
+	"\vtransfer_us\x18\x06 \x01(\x03R\n" +
+	"transferUs\x12+\n" +
+	"\x11connection_reused\x18\a \x01(\bR\x10connectionReused\"\x9d\x01\n" +
+	"\x0fServerSentEvent\x12\x17\n" +
+	"\aflow_id\x18\x01 \x01(\tR\x06flowId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x14\n" +
+	"\x05event\x18\x03 \x01(\tR\x05event\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x12\x14\n" +
+	"\x05retry\x18\x05 \x01(\x03R\x05retry\x12!\n" +
+	"\ftimestamp_ms\x18\x06 \x01(\x03R\vtimestampMs\"\x94\x02\n" +
+	"\x0eWebSocketFrame\x12\x17\n" +
+	"\aflow_id\x18\x01 \x01(\tR\x06flowId\x122\n" +
+	"\tdirection\x18\x02 \x01(\x0e2\x14.apix.FrameDirectionR\tdirection\x12\x16\n" +
+	"\x06opcode\x18\x03 \x01(\x05R\x06opcode\x12\x10\n" +
+	"\x03fin\x18\x04 \x01(\bR\x03fin\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayload\x12!\n" +
+	"\fpayload_size\x18\x06 \x01(\x03R\vpayloadSize\x12+\n" +
+	"\x11payload_truncated\x18\a \x01(\bR\x10payloadTruncated\x12!\n" +
+	"\ftimestamp_ms\x18\b \x01(\x03R\vtimestampMs\"\\\n" +
 	"\n" +
 	"PluginInfo\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12 \n" +
 	"\vdescription\x18\x03 \x01(\tR\vdescription\"\x0f\n" +
-	"\rStatusRequest\"\x10\n" +
-	"\x0eCaptureRequest\"\x13\n" +
-	"\x11PluginListRequest\"B\n" +
+	"\rStatusRequest\"\xd5\x01\n" +
+	"\x0eCaptureRequest\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x1f\n" +
+	"\vbuffer_size\x18\x02 \x01(\x05R\n" +
+	"bufferSize\x12(\n" +
+	"\x10block_timeout_ms\x18\x03 \x01(\x03R\x0eblockTimeoutMs\x12\x19\n" +
+	"\bfrom_seq\x18\x04 \x01(\x04R\afromSeq\x12\x12\n" +
+	"\x04last\x18\x05 \x01(\x05R\x04last\x12\x19\n" +
+	"\bsince_ms\x18\x06 \x01(\x03R\asinceMs\x12\x16\n" +
+	"\x06filter\x18\a \x01(\tR\x06filter\"2\n" +
+	"\x17WebSocketCaptureRequest\x12\x17\n" +
+	"\aflow_id\x18\x01 \x01(\tR\x06flowId\"8\n" +
+	"\x1dServerSentEventCaptureRequest\x12\x17\n" +
+	"\aflow_id\x18\x01 \x01(\tR\x06flowId\"\x13\n" +
+	"\x11PluginListRequest\"\x18\n" +
+	"\x16NetworkProfilesRequest\"H\n" +
+	"\x18SetNetworkProfileRequest\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\"\x16\n" +
+	"\x14HostOverridesRequest\"z\n" +
+	"\x10ListFlowsRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\x92\x01\n" +
+	"\x12SearchFlowsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x16\n" +
+	"\x06filter\x18\x02 \x01(\tR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\" \n" +
+	"\x0eGetFlowRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\">\n" +
+	"\x12DeleteFlowsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12\x16\n" +
+	"\x06filter\x18\x02 \x01(\tR\x06filter\"\x13\n" +
+	"\x11ClearFlowsRequest\"v\n" +
 	"\x0eStatusResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
-	"\aversion\x18\x02 \x01(\tR\aversion\"@\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x122\n" +
+	"\tretention\x18\x03 \x01(\v2\x14.apix.RetentionStatsR\tretention\"\xe0\x02\n" +
+	"\x0eRetentionStats\x12\x14\n" +
+	"\x05flows\x18\x01 \x01(\x03R\x05flows\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x03R\x05bytes\x12\x1b\n" +
+	"\tmax_flows\x18\x03 \x01(\x03R\bmaxFlows\x12\x1b\n" +
+	"\tmax_bytes\x18\x04 \x01(\x03R\bmaxBytes\x12&\n" +
+	"\x0fmax_age_seconds\x18\x05 \x01(\x03R\rmaxAgeSeconds\x12#\n" +
+	"\revicted_flows\x18\x06 \x01(\x03R\fevictedFlows\x12#\n" +
+	"\revicted_bytes\x18\a \x01(\x03R\fevictedBytes\x12(\n" +
+	"\x10evicted_by_count\x18\b \x01(\x03R\x0eevictedByCount\x12&\n" +
+	"\x0fevicted_by_size\x18\t \x01(\x03R\revictedBySize\x12$\n" +
+	"\x0eevicted_by_age\x18\n" +
+	" \x01(\x03R\fevictedByAge\"@\n" +
 	"\x12PluginListResponse\x12*\n" +
-	"\aplugins\x18\x01 \x03(\v2\x10.apix.PluginInfoR\aplugins2\xbf\x01\n" +
+	"\aplugins\x18\x01 \x03(\v2\x10.apix.PluginInfoR\aplugins\"\x8d\x01\n" +
+	"\x17NetworkProfilesResponse\x120\n" +
+	"\bprofiles\x18\x01 \x03(\v2\x14.apix.NetworkProfileR\bprofiles\x12@\n" +
+	"\vassignments\x18\x02 \x03(\v2\x1e.apix.NetworkProfileAssignmentR\vassignments\"e\n" +
+	"\x15HostOverridesResponse\x120\n" +
+	"\toverrides\x18\x01 \x03(\v2\x12.apix.HostOverrideR\toverrides\x12\x1a\n" +
+	"\bresolver\x18\x02 \x01(\tR\bresolver\"s\n" +
+	"\x11ListFlowsResponse\x12 \n" +
+	"\x05flows\x18\x01 \x03(\v2\n" +
+	".apix.FlowR\x05flows\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x03R\x05total\"/\n" +
+	"\x13DeleteFlowsResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\x03R\adeleted*<\n" +
+	"\x0eFrameDirection\x12\x14\n" +
+	"\x10CLIENT_TO_SERVER\x10\x00\x12\x14\n" +
+	"\x10SERVER_TO_CLIENT\x10\x012\xc7\a\n" +
 	"\x06Engine\x126\n" +
-	"\tGetStatus\x12\x13.apix.StatusRequest\x1a\x14.apix.StatusResponse\x12;\n" +
-	"\x0eCaptureTraffic\x12\x14.apix.CaptureRequest\x1a\x11.apix.HttpRequest0\x01\x12@\n" +
-	"\vListPlugins\x12\x17.apix.PluginListRequest\x1a\x18.apix.PluginListResponseB6Z4github.com/mnafshin/apix/pkg/api/generated;generatedb\x06proto3"
+	"\tGetStatus\x12\x13.apix.StatusRequest\x1a\x14.apix.StatusResponse\x124\n" +
+	"\x0eCaptureTraffic\x12\x14.apix.CaptureRequest\x1a\n" +
+	".apix.Flow0\x01\x12I\n" +
+	"\x10CaptureWebSocket\x12\x1d.apix.WebSocketCaptureRequest\x1a\x14.apix.WebSocketFrame0\x01\x12W\n" +
+	"\x17CaptureServerSentEvents\x12#.apix.ServerSentEventCaptureRequest\x1a\x15.apix.ServerSentEvent0\x01\x12@\n" +
+	"\vListPlugins\x12\x17.apix.PluginListRequest\x1a\x18.apix.PluginListResponse\x12Q\n" +
+	"\x12GetNetworkProfiles\x12\x1c.apix.NetworkProfilesRequest\x1a\x1d.apix.NetworkProfilesResponse\x12R\n" +
+	"\x11SetNetworkProfile\x12\x1e.apix.SetNetworkProfileRequest\x1a\x1d.apix.NetworkProfilesResponse\x12K\n" +
+	"\x10GetHostOverrides\x12\x1a.apix.HostOverridesRequest\x1a\x1b.apix.HostOverridesResponse\x12B\n" +
+	"\x0fSetHostOverride\x12\x12.apix.HostOverride\x1a\x1b.apix.HostOverridesResponse\x12<\n" +
+	"\tListFlows\x12\x16.apix.ListFlowsRequest\x1a\x17.apix.ListFlowsResponse\x12+\n" +
+	"\aGetFlow\x12\x14.apix.GetFlowRequest\x1a\n" +
+	".apix.Flow\x12@\n" +
+	"\vSearchFlows\x12\x18.apix.SearchFlowsRequest\x1a\x17.apix.ListFlowsResponse\x12B\n" +
+	"\vDeleteFlows\x12\x18.apix.DeleteFlowsRequest\x1a\x19.apix.DeleteFlowsResponse\x12@\n" +
+	"\n" +
+	"ClearFlows\x12\x17.apix.ClearFlowsRequest\x1a\x19.apix.DeleteFlowsResponseB6Z4github.com/mnafshin/apix/pkg/api/generated;generatedb\x06proto3"
 
 var (
 	file_apix_proto_rawDescOnce sync.Once
@@ -480,34 +2876,105 @@ func file_apix_proto_rawDescGZIP() []byte {
 	return file_apix_proto_rawDescData
 }
 
-var file_apix_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_apix_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_apix_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_apix_proto_goTypes = []any{
-	(*HttpRequest)(nil),        // 0: apix.HttpRequest
-	(*HttpResponse)(nil),       // 1: apix.HttpResponse
-	(*PluginInfo)(nil),         // 2: apix.PluginInfo
-	(*StatusRequest)(nil),      // 3: apix.StatusRequest
-	(*CaptureRequest)(nil),     // 4: apix.CaptureRequest
-	(*PluginListRequest)(nil),  // 5: apix.PluginListRequest
-	(*StatusResponse)(nil),     // 6: apix.StatusResponse
-	(*PluginListResponse)(nil), // 7: apix.PluginListResponse
-	nil,                        // 8: apix.HttpRequest.HeadersEntry
-	nil,                        // 9: apix.HttpResponse.HeadersEntry
+	(FrameDirection)(0),                   // 0: apix.FrameDirection
+	(*Header)(nil),                        // 1: apix.Header
+	(*HttpRequest)(nil),                   // 2: apix.HttpRequest
+	(*HttpResponse)(nil),                  // 3: apix.HttpResponse
+	(*Tunnel)(nil),                        // 4: apix.Tunnel
+	(*Flow)(nil),                          // 5: apix.Flow
+	(*Gap)(nil),                           // 6: apix.Gap
+	(*TLSInfo)(nil),                       // 7: apix.TLSInfo
+	(*Certificate)(nil),                   // 8: apix.Certificate
+	(*Fault)(nil),                         // 9: apix.Fault
+	(*NetworkProfile)(nil),                // 10: apix.NetworkProfile
+	(*NetworkProfileAssignment)(nil),      // 11: apix.NetworkProfileAssignment
+	(*HostOverride)(nil),                  // 12: apix.HostOverride
+	(*Timing)(nil),                        // 13: apix.Timing
+	(*ServerSentEvent)(nil),               // 14: apix.ServerSentEvent
+	(*WebSocketFrame)(nil),                // 15: apix.WebSocketFrame
+	(*PluginInfo)(nil),                    // 16: apix.PluginInfo
+	(*StatusRequest)(nil),                 // 17: apix.StatusRequest
+	(*CaptureRequest)(nil),                // 18: apix.CaptureRequest
+	(*WebSocketCaptureRequest)(nil),       // 19: apix.WebSocketCaptureRequest
+	(*ServerSentEventCaptureRequest)(nil), // 20: apix.ServerSentEventCaptureRequest
+	(*PluginListRequest)(nil),             // 21: apix.PluginListRequest
+	(*NetworkProfilesRequest)(nil),        // 22: apix.NetworkProfilesRequest
+	(*SetNetworkProfileRequest)(nil),      // 23: apix.SetNetworkProfileRequest
+	(*HostOverridesRequest)(nil),          // 24: apix.HostOverridesRequest
+	(*ListFlowsRequest)(nil),              // 25: apix.ListFlowsRequest
+	(*SearchFlowsRequest)(nil),            // 26: apix.SearchFlowsRequest
+	(*GetFlowRequest)(nil),                // 27: apix.GetFlowRequest
+	(*DeleteFlowsRequest)(nil),            // 28: apix.DeleteFlowsRequest
+	(*ClearFlowsRequest)(nil),             // 29: apix.ClearFlowsRequest
+	(*StatusResponse)(nil),                // 30: apix.StatusResponse
+	(*RetentionStats)(nil),                // 31: apix.RetentionStats
+	(*PluginListResponse)(nil),            // 32: apix.PluginListResponse
+	(*NetworkProfilesResponse)(nil),       // 33: apix.NetworkProfilesResponse
+	(*HostOverridesResponse)(nil),         // 34: apix.HostOverridesResponse
+	(*ListFlowsResponse)(nil),             // 35: apix.ListFlowsResponse
+	(*DeleteFlowsResponse)(nil),           // 36: apix.DeleteFlowsResponse
+	nil,                                   // 37: apix.HttpRequest.HeadersEntry
+	nil,                                   // 38: apix.HttpResponse.HeadersEntry
 }
 var file_apix_proto_depIdxs = []int32{
-	8, // 0: apix.HttpRequest.headers:type_name -> apix.HttpRequest.HeadersEntry
-	9, // 1: apix.HttpResponse.headers:type_name -> apix.HttpResponse.HeadersEntry
-	2, // 2: apix.PluginListResponse.plugins:type_name -> apix.PluginInfo
-	3, // 3: apix.Engine.GetStatus:input_type -> apix.StatusRequest
-	4, // 4: apix.Engine.CaptureTraffic:input_type -> apix.CaptureRequest
-	5, // 5: apix.Engine.ListPlugins:input_type -> apix.PluginListRequest
-	6, // 6: apix.Engine.GetStatus:output_type -> apix.StatusResponse
-	0, // 7: apix.Engine.CaptureTraffic:output_type -> apix.HttpRequest
-	7, // 8: apix.Engine.ListPlugins:output_type -> apix.PluginListResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	37, // 0: apix.HttpRequest.headers:type_name -> apix.HttpRequest.HeadersEntry
+	1,  // 1: apix.HttpRequest.header_list:type_name -> apix.Header
+	1,  // 2: apix.HttpRequest.trailers:type_name -> apix.Header
+	38, // 3: apix.HttpResponse.headers:type_name -> apix.HttpResponse.HeadersEntry
+	1,  // 4: apix.HttpResponse.header_list:type_name -> apix.Header
+	1,  // 5: apix.HttpResponse.trailers:type_name -> apix.Header
+	2,  // 6: apix.Flow.request:type_name -> apix.HttpRequest
+	3,  // 7: apix.Flow.response:type_name -> apix.HttpResponse
+	4,  // 8: apix.Flow.tunnel:type_name -> apix.Tunnel
+	14, // 9: apix.Flow.sse_events:type_name -> apix.ServerSentEvent
+	13, // 10: apix.Flow.timing:type_name -> apix.Timing
+	9,  // 11: apix.Flow.fault:type_name -> apix.Fault
+	7,  // 12: apix.Flow.upstream_tls:type_name -> apix.TLSInfo
+	6,  // 13: apix.Flow.gap:type_name -> apix.Gap
+	8,  // 14: apix.TLSInfo.peer_certificates:type_name -> apix.Certificate
+	0,  // 15: apix.WebSocketFrame.direction:type_name -> apix.FrameDirection
+	31, // 16: apix.StatusResponse.retention:type_name -> apix.RetentionStats
+	16, // 17: apix.PluginListResponse.plugins:type_name -> apix.PluginInfo
+	10, // 18: apix.NetworkProfilesResponse.profiles:type_name -> apix.NetworkProfile
+	11, // 19: apix.NetworkProfilesResponse.assignments:type_name -> apix.NetworkProfileAssignment
+	12, // 20: apix.HostOverridesResponse.overrides:type_name -> apix.HostOverride
+	5,  // 21: apix.ListFlowsResponse.flows:type_name -> apix.Flow
+	17, // 22: apix.Engine.GetStatus:input_type -> apix.StatusRequest
+	18, // 23: apix.Engine.CaptureTraffic:input_type -> apix.CaptureRequest
+	19, // 24: apix.Engine.CaptureWebSocket:input_type -> apix.WebSocketCaptureRequest
+	20, // 25: apix.Engine.CaptureServerSentEvents:input_type -> apix.ServerSentEventCaptureRequest
+	21, // 26: apix.Engine.ListPlugins:input_type -> apix.PluginListRequest
+	22, // 27: apix.Engine.GetNetworkProfiles:input_type -> apix.NetworkProfilesRequest
+	23, // 28: apix.Engine.SetNetworkProfile:input_type -> apix.SetNetworkProfileRequest
+	24, // 29: apix.Engine.GetHostOverrides:input_type -> apix.HostOverridesRequest
+	12, // 30: apix.Engine.SetHostOverride:input_type -> apix.HostOverride
+	25, // 31: apix.Engine.ListFlows:input_type -> apix.ListFlowsRequest
+	27, // 32: apix.Engine.GetFlow:input_type -> apix.GetFlowRequest
+	26, // 33: apix.Engine.SearchFlows:input_type -> apix.SearchFlowsRequest
+	28, // 34: apix.Engine.DeleteFlows:input_type -> apix.DeleteFlowsRequest
+	29, // 35: apix.Engine.ClearFlows:input_type -> apix.ClearFlowsRequest
+	30, // 36: apix.Engine.GetStatus:output_type -> apix.StatusResponse
+	5,  // 37: apix.Engine.CaptureTraffic:output_type -> apix.Flow
+	15, // 38: apix.Engine.CaptureWebSocket:output_type -> apix.WebSocketFrame
+	14, // 39: apix.Engine.CaptureServerSentEvents:output_type -> apix.ServerSentEvent
+	32, // 40: apix.Engine.ListPlugins:output_type -> apix.PluginListResponse
+	33, // 41: apix.Engine.GetNetworkProfiles:output_type -> apix.NetworkProfilesResponse
+	33, // 42: apix.Engine.SetNetworkProfile:output_type -> apix.NetworkProfilesResponse
+	34, // 43: apix.Engine.GetHostOverrides:output_type -> apix.HostOverridesResponse
+	34, // 44: apix.Engine.SetHostOverride:output_type -> apix.HostOverridesResponse
+	35, // 45: apix.Engine.ListFlows:output_type -> apix.ListFlowsResponse
+	5,  // 46: apix.Engine.GetFlow:output_type -> apix.Flow
+	35, // 47: apix.Engine.SearchFlows:output_type -> apix.ListFlowsResponse
+	36, // 48: apix.Engine.DeleteFlows:output_type -> apix.DeleteFlowsResponse
+	36, // 49: apix.Engine.ClearFlows:output_type -> apix.DeleteFlowsResponse
+	36, // [36:50] is the sub-list for method output_type
+	22, // [22:36] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_apix_proto_init() }
@@ -520,13 +2987,14 @@ func file_apix_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apix_proto_rawDesc), len(file_apix_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      1,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_apix_proto_goTypes,
 		DependencyIndexes: file_apix_proto_depIdxs,
+		EnumInfos:         file_apix_proto_enumTypes,
 		MessageInfos:      file_apix_proto_msgTypes,
 	}.Build()
 	File_apix_proto = out.File
@@ -19,9 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Engine_GetStatus_FullMethodName      = "/apix.Engine/GetStatus"
-	Engine_CaptureTraffic_FullMethodName = "/apix.Engine/CaptureTraffic"
-	Engine_ListPlugins_FullMethodName    = "/apix.Engine/ListPlugins"
+	Engine_GetStatus_FullMethodName               = "/apix.Engine/GetStatus"
+	Engine_CaptureTraffic_FullMethodName          = "/apix.Engine/CaptureTraffic"
+	Engine_CaptureWebSocket_FullMethodName        = "/apix.Engine/CaptureWebSocket"
+	Engine_CaptureServerSentEvents_FullMethodName = "/apix.Engine/CaptureServerSentEvents"
+	Engine_ListPlugins_FullMethodName             = "/apix.Engine/ListPlugins"
+	Engine_GetNetworkProfiles_FullMethodName      = "/apix.Engine/GetNetworkProfiles"
+	Engine_SetNetworkProfile_FullMethodName       = "/apix.Engine/SetNetworkProfile"
+	Engine_GetHostOverrides_FullMethodName        = "/apix.Engine/GetHostOverrides"
+	Engine_SetHostOverride_FullMethodName         = "/apix.Engine/SetHostOverride"
+	Engine_ListFlows_FullMethodName               = "/apix.Engine/ListFlows"
+	Engine_GetFlow_FullMethodName                 = "/apix.Engine/GetFlow"
+	Engine_SearchFlows_FullMethodName             = "/apix.Engine/SearchFlows"
+	Engine_DeleteFlows_FullMethodName             = "/apix.Engine/DeleteFlows"
+	Engine_ClearFlows_FullMethodName              = "/apix.Engine/ClearFlows"
 )
 
 // EngineClient is the client API for Engine service.
@@ -30,10 +41,35 @@ const (
 type EngineClient interface {
 	// Health check
 	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
-	// Stream captured HTTP requests
-	CaptureTraffic(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HttpRequest], error)
+	// Stream captured flows; streaming responses are sent when their headers
+	// arrive and again once they complete. Flows missed by a subscriber that
+	// falls behind are reported as gaps, or end the stream with
+	// RESOURCE_EXHAUSTED under the disconnect policy.
+	CaptureTraffic(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Flow], error)
+	// Stream WebSocket frames of upgraded flows
+	CaptureWebSocket(ctx context.Context, in *WebSocketCaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WebSocketFrame], error)
+	// Stream events of text/event-stream responses as they arrive
+	CaptureServerSentEvents(ctx context.Context, in *ServerSentEventCaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServerSentEvent], error)
 	// List installed plugins
 	ListPlugins(ctx context.Context, in *PluginListRequest, opts ...grpc.CallOption) (*PluginListResponse, error)
+	// List network profiles and where they are applied
+	GetNetworkProfiles(ctx context.Context, in *NetworkProfilesRequest, opts ...grpc.CallOption) (*NetworkProfilesResponse, error)
+	// Apply a network profile to a host or globally
+	SetNetworkProfile(ctx context.Context, in *SetNetworkProfileRequest, opts ...grpc.CallOption) (*NetworkProfilesResponse, error)
+	// List host overrides of the upstream resolver
+	GetHostOverrides(ctx context.Context, in *HostOverridesRequest, opts ...grpc.CallOption) (*HostOverridesResponse, error)
+	// Add, change or, with an empty target, remove a host override
+	SetHostOverride(ctx context.Context, in *HostOverride, opts ...grpc.CallOption) (*HostOverridesResponse, error)
+	// List stored flows without their bodies
+	ListFlows(ctx context.Context, in *ListFlowsRequest, opts ...grpc.CallOption) (*ListFlowsResponse, error)
+	// Get a stored flow with its full headers and bodies
+	GetFlow(ctx context.Context, in *GetFlowRequest, opts ...grpc.CallOption) (*Flow, error)
+	// List stored flows, without their bodies, that contain some text
+	SearchFlows(ctx context.Context, in *SearchFlowsRequest, opts ...grpc.CallOption) (*ListFlowsResponse, error)
+	// Delete stored flows
+	DeleteFlows(ctx context.Context, in *DeleteFlowsRequest, opts ...grpc.CallOption) (*DeleteFlowsResponse, error)
+	// Delete every stored flow
+	ClearFlows(ctx context.Context, in *ClearFlowsRequest, opts ...grpc.CallOption) (*DeleteFlowsResponse, error)
 }
 
 type engineClient struct {
@@ -54,13 +90,13 @@ func (c *engineClient) GetStatus(ctx context.Context, in *StatusRequest, opts ..
 	return out, nil
 }
 
-func (c *engineClient) CaptureTraffic(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HttpRequest], error) {
+func (c *engineClient) CaptureTraffic(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Flow], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Engine_ServiceDesc.Streams[0], Engine_CaptureTraffic_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[CaptureRequest, HttpRequest]{ClientStream: stream}
+	x := &grpc.GenericClientStream[CaptureRequest, Flow]{ClientStream: stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -71,7 +107,45 @@ func (c *engineClient) CaptureTraffic(ctx context.Context, in *CaptureRequest, o
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Engine_CaptureTrafficClient = grpc.ServerStreamingClient[HttpRequest]
+type Engine_CaptureTrafficClient = grpc.ServerStreamingClient[Flow]
+
+func (c *engineClient) CaptureWebSocket(ctx context.Context, in *WebSocketCaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WebSocketFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Engine_ServiceDesc.Streams[1], Engine_CaptureWebSocket_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WebSocketCaptureRequest, WebSocketFrame]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Engine_CaptureWebSocketClient = grpc.ServerStreamingClient[WebSocketFrame]
+
+func (c *engineClient) CaptureServerSentEvents(ctx context.Context, in *ServerSentEventCaptureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServerSentEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Engine_ServiceDesc.Streams[2], Engine_CaptureServerSentEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ServerSentEventCaptureRequest, ServerSentEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Engine_CaptureServerSentEventsClient = grpc.ServerStreamingClient[ServerSentEvent]
 
 func (c *engineClient) ListPlugins(ctx context.Context, in *PluginListRequest, opts ...grpc.CallOption) (*PluginListResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
@@ -83,16 +157,131 @@ func (c *engineClient) ListPlugins(ctx context.Context, in *PluginListRequest, o
 	return out, nil
 }
 
+func (c *engineClient) GetNetworkProfiles(ctx context.Context, in *NetworkProfilesRequest, opts ...grpc.CallOption) (*NetworkProfilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NetworkProfilesResponse)
+	err := c.cc.Invoke(ctx, Engine_GetNetworkProfiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) SetNetworkProfile(ctx context.Context, in *SetNetworkProfileRequest, opts ...grpc.CallOption) (*NetworkProfilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NetworkProfilesResponse)
+	err := c.cc.Invoke(ctx, Engine_SetNetworkProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) GetHostOverrides(ctx context.Context, in *HostOverridesRequest, opts ...grpc.CallOption) (*HostOverridesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HostOverridesResponse)
+	err := c.cc.Invoke(ctx, Engine_GetHostOverrides_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) SetHostOverride(ctx context.Context, in *HostOverride, opts ...grpc.CallOption) (*HostOverridesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HostOverridesResponse)
+	err := c.cc.Invoke(ctx, Engine_SetHostOverride_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) ListFlows(ctx context.Context, in *ListFlowsRequest, opts ...grpc.CallOption) (*ListFlowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFlowsResponse)
+	err := c.cc.Invoke(ctx, Engine_ListFlows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) GetFlow(ctx context.Context, in *GetFlowRequest, opts ...grpc.CallOption) (*Flow, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Flow)
+	err := c.cc.Invoke(ctx, Engine_GetFlow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) SearchFlows(ctx context.Context, in *SearchFlowsRequest, opts ...grpc.CallOption) (*ListFlowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFlowsResponse)
+	err := c.cc.Invoke(ctx, Engine_SearchFlows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) DeleteFlows(ctx context.Context, in *DeleteFlowsRequest, opts ...grpc.CallOption) (*DeleteFlowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFlowsResponse)
+	err := c.cc.Invoke(ctx, Engine_DeleteFlows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) ClearFlows(ctx context.Context, in *ClearFlowsRequest, opts ...grpc.CallOption) (*DeleteFlowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFlowsResponse)
+	err := c.cc.Invoke(ctx, Engine_ClearFlows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EngineServer is the server API for Engine service.
 // All implementations must embed UnimplementedEngineServer
 // for forward compatibility.
 type EngineServer interface {
 	// Health check
 	GetStatus(context.Context, *StatusRequest) (*StatusResponse, error)
-	// Stream captured HTTP requests
-	CaptureTraffic(*CaptureRequest, grpc.ServerStreamingServer[HttpRequest]) error
+	// Stream captured flows; streaming responses are sent when their headers
+	// arrive and again once they complete. Flows missed by a subscriber that
+	// falls behind are reported as gaps, or end the stream with
+	// RESOURCE_EXHAUSTED under the disconnect policy.
+	CaptureTraffic(*CaptureRequest, grpc.ServerStreamingServer[Flow]) error
+	// Stream WebSocket frames of upgraded flows
+	CaptureWebSocket(*WebSocketCaptureRequest, grpc.ServerStreamingServer[WebSocketFrame]) error
+	// Stream events of text/event-stream responses as they arrive
+	CaptureServerSentEvents(*ServerSentEventCaptureRequest, grpc.ServerStreamingServer[ServerSentEvent]) error
 	// List installed plugins
 	ListPlugins(context.Context, *PluginListRequest) (*PluginListResponse, error)
+	// List network profiles and where they are applied
+	GetNetworkProfiles(context.Context, *NetworkProfilesRequest) (*NetworkProfilesResponse, error)
+	// Apply a network profile to a host or globally
+	SetNetworkProfile(context.Context, *SetNetworkProfileRequest) (*NetworkProfilesResponse, error)
+	// List host overrides of the upstream resolver
+	GetHostOverrides(context.Context, *HostOverridesRequest) (*HostOverridesResponse, error)
+	// Add, change or, with an empty target, remove a host override
+	SetHostOverride(context.Context, *HostOverride) (*HostOverridesResponse, error)
+	// List stored flows without their bodies
+	ListFlows(context.Context, *ListFlowsRequest) (*ListFlowsResponse, error)
+	// Get a stored flow with its full headers and bodies
+	GetFlow(context.Context, *GetFlowRequest) (*Flow, error)
+	// List stored flows, without their bodies, that contain some text
+	SearchFlows(context.Context, *SearchFlowsRequest) (*ListFlowsResponse, error)
+	// Delete stored flows
+	DeleteFlows(context.Context, *DeleteFlowsRequest) (*DeleteFlowsResponse, error)
+	// Delete every stored flow
+	ClearFlows(context.Context, *ClearFlowsRequest) (*DeleteFlowsResponse, error)
 	mustEmbedUnimplementedEngineServer()
 }
 
@@ -106,12 +295,45 @@ type UnimplementedEngineServer struct{}
 func (UnimplementedEngineServer) GetStatus(context.Context, *StatusRequest) (*StatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
 }
-func (UnimplementedEngineServer) CaptureTraffic(*CaptureRequest, grpc.ServerStreamingServer[HttpRequest]) error {
+func (UnimplementedEngineServer) CaptureTraffic(*CaptureRequest, grpc.ServerStreamingServer[Flow]) error {
 	return status.Errorf(codes.Unimplemented, "method CaptureTraffic not implemented")
 }
+func (UnimplementedEngineServer) CaptureWebSocket(*WebSocketCaptureRequest, grpc.ServerStreamingServer[WebSocketFrame]) error {
+	return status.Errorf(codes.Unimplemented, "method CaptureWebSocket not implemented")
+}
+func (UnimplementedEngineServer) CaptureServerSentEvents(*ServerSentEventCaptureRequest, grpc.ServerStreamingServer[ServerSentEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method CaptureServerSentEvents not implemented")
+}
 func (UnimplementedEngineServer) ListPlugins(context.Context, *PluginListRequest) (*PluginListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListPlugins not implemented")
 }
+func (UnimplementedEngineServer) GetNetworkProfiles(context.Context, *NetworkProfilesRequest) (*NetworkProfilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkProfiles not implemented")
+}
+func (UnimplementedEngineServer) SetNetworkProfile(context.Context, *SetNetworkProfileRequest) (*NetworkProfilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetNetworkProfile not implemented")
+}
+func (UnimplementedEngineServer) GetHostOverrides(context.Context, *HostOverridesRequest) (*HostOverridesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHostOverrides not implemented")
+}
+func (UnimplementedEngineServer) SetHostOverride(context.Context, *HostOverride) (*HostOverridesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetHostOverride not implemented")
+}
+func (UnimplementedEngineServer) ListFlows(context.Context, *ListFlowsRequest) (*ListFlowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFlows not implemented")
+}
+func (UnimplementedEngineServer) GetFlow(context.Context, *GetFlowRequest) (*Flow, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlow not implemented")
+}
+func (UnimplementedEngineServer) SearchFlows(context.Context, *SearchFlowsRequest) (*ListFlowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchFlows not implemented")
+}
+func (UnimplementedEngineServer) DeleteFlows(context.Context, *DeleteFlowsRequest) (*DeleteFlowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFlows not implemented")
+}
+func (UnimplementedEngineServer) ClearFlows(context.Context, *ClearFlowsRequest) (*DeleteFlowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearFlows not implemented")
+}
 func (UnimplementedEngineServer) mustEmbedUnimplementedEngineServer() {}
 func (UnimplementedEngineServer) testEmbeddedByValue()                {}
 
@@ -156,11 +378,33 @@ func _Engine_CaptureTraffic_Handler(srv interface{}, stream grpc.ServerStream) e
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(EngineServer).CaptureTraffic(m, &grpc.GenericServerStream[CaptureRequest, HttpRequest]{ServerStream: stream})
+	return srv.(EngineServer).CaptureTraffic(m, &grpc.GenericServerStream[CaptureRequest, Flow]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Engine_CaptureTrafficServer = grpc.ServerStreamingServer[Flow]
+
+func _Engine_CaptureWebSocket_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WebSocketCaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineServer).CaptureWebSocket(m, &grpc.GenericServerStream[WebSocketCaptureRequest, WebSocketFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Engine_CaptureWebSocketServer = grpc.ServerStreamingServer[WebSocketFrame]
+
+func _Engine_CaptureServerSentEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ServerSentEventCaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineServer).CaptureServerSentEvents(m, &grpc.GenericServerStream[ServerSentEventCaptureRequest, ServerSentEvent]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Engine_CaptureTrafficServer = grpc.ServerStreamingServer[HttpRequest]
+type Engine_CaptureServerSentEventsServer = grpc.ServerStreamingServer[ServerSentEvent]
 
 func _Engine_ListPlugins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PluginListRequest)
@@ -180,6 +424,168 @@ func _Engine_ListPlugins_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Engine_GetNetworkProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetworkProfilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).GetNetworkProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_GetNetworkProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).GetNetworkProfiles(ctx, req.(*NetworkProfilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_SetNetworkProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetNetworkProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).SetNetworkProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_SetNetworkProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).SetNetworkProfile(ctx, req.(*SetNetworkProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_GetHostOverrides_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostOverridesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).GetHostOverrides(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_GetHostOverrides_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).GetHostOverrides(ctx, req.(*HostOverridesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_SetHostOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostOverride)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).SetHostOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_SetHostOverride_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).SetHostOverride(ctx, req.(*HostOverride))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_ListFlows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFlowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).ListFlows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_ListFlows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).ListFlows(ctx, req.(*ListFlowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_GetFlow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFlowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).GetFlow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_GetFlow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).GetFlow(ctx, req.(*GetFlowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_SearchFlows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchFlowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).SearchFlows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_SearchFlows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).SearchFlows(ctx, req.(*SearchFlowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_DeleteFlows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFlowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).DeleteFlows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_DeleteFlows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).DeleteFlows(ctx, req.(*DeleteFlowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_ClearFlows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearFlowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).ClearFlows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_ClearFlows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).ClearFlows(ctx, req.(*ClearFlowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Engine_ServiceDesc is the grpc.ServiceDesc for Engine service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,6 +601,42 @@ var Engine_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPlugins",
 			Handler:    _Engine_ListPlugins_Handler,
 		},
+		{
+			MethodName: "GetNetworkProfiles",
+			Handler:    _Engine_GetNetworkProfiles_Handler,
+		},
+		{
+			MethodName: "SetNetworkProfile",
+			Handler:    _Engine_SetNetworkProfile_Handler,
+		},
+		{
+			MethodName: "GetHostOverrides",
+			Handler:    _Engine_GetHostOverrides_Handler,
+		},
+		{
+			MethodName: "SetHostOverride",
+			Handler:    _Engine_SetHostOverride_Handler,
+		},
+		{
+			MethodName: "ListFlows",
+			Handler:    _Engine_ListFlows_Handler,
+		},
+		{
+			MethodName: "GetFlow",
+			Handler:    _Engine_GetFlow_Handler,
+		},
+		{
+			MethodName: "SearchFlows",
+			Handler:    _Engine_SearchFlows_Handler,
+		},
+		{
+			MethodName: "DeleteFlows",
+			Handler:    _Engine_DeleteFlows_Handler,
+		},
+		{
+			MethodName: "ClearFlows",
+			Handler:    _Engine_ClearFlows_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -202,6 +644,16 @@ var Engine_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Engine_CaptureTraffic_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "CaptureWebSocket",
+			Handler:       _Engine_CaptureWebSocket_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CaptureServerSentEvents",
+			Handler:       _Engine_CaptureServerSentEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "apix.proto",
 }
@@ -0,0 +1,179 @@
+// Package tamper modifies proxied HTTP exchanges. It injects faults so
+// clients can be tested against failing upstreams (error statuses,
+// connection resets, hangs, truncated bodies and malformed responses, each
+// applied to a share of the requests matching a URL pattern), it maps
+// requests to local files that are served in place of the upstream, and it
+// rewrites the destination of requests to other URLs (Map Remote).
+package tamper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the type of fault a rule injects.
+type Kind string
+
+const (
+	// Status answers with an error status instead of calling upstream.
+	Status Kind = "status"
+	// Reset aborts the client connection before a response is sent.
+	Reset Kind = "reset"
+	// Hang holds the request without answering, then aborts it.
+	Hang Kind = "hang"
+	// Truncate forwards the upstream response but cuts its body short.
+	Truncate Kind = "truncate"
+	// Malformed answers with bytes that are not a valid HTTP response.
+	Malformed Kind = "malformed"
+)
+
+// MalformedResponse is what a Malformed fault writes to the client.
+const MalformedResponse = "HTTP/1.1 2OO OK\r\nContent-Length: -1\r\nContent-Type text/html\r\n\r\n\x00\x00<html>"
+
+// ErrTruncated ends the body of a response cut short by a Truncate fault.
+var ErrTruncated = errors.New("tamper: response body truncated by fault injection")
+
+// Rule injects a fault into a share of the requests it matches.
+type Rule struct {
+	// URL is a pattern for the full request URL in which * matches any run
+	// of characters, e.g. "https://api.example.com/v1/*". Patterns without
+	// a scheme match any scheme.
+	URL string
+	// Methods limits the rule to these request methods; empty means all.
+	Methods []string
+	Kind    Kind
+	// Probability is the chance, from 0 to 1, that a matching request
+	// fails. Zero disables the rule.
+	Probability float64
+
+	// Status and Body make up the response of a Status fault. Status
+	// defaults to 503.
+	Status int
+	Body   string
+	// Duration is how long a Hang fault holds the request before aborting
+	// it. Zero hangs until the client gives up.
+	Duration time.Duration
+	// TruncateAt is the number of body bytes a Truncate fault lets through.
+	// Zero cuts the body in half, or right after the headers when its
+	// length is unknown.
+	TruncateAt int64
+
+	pattern *regexp.Regexp
+}
+
+// String describes the fault, e.g. "status 503" or "truncate at 1024 bytes".
+func (r *Rule) String() string {
+	switch r.Kind {
+	case Status:
+		return fmt.Sprintf("status %d", r.Status)
+	case Hang:
+		if r.Duration > 0 {
+			return fmt.Sprintf("hang for %s", r.Duration)
+		}
+		return "hang"
+	case Truncate:
+		if r.TruncateAt > 0 {
+			return fmt.Sprintf("truncate at %d bytes", r.TruncateAt)
+		}
+		return "truncate"
+	}
+	return string(r.Kind)
+}
+
+// Engine picks the fault, if any, for each request. Rules are tried in the
+// order they were added; a matching rule whose dice roll misses leaves the
+// request to the rules after it.
+type Engine struct {
+	mu    sync.RWMutex
+	rules []*Rule
+}
+
+func New() *Engine {
+	return &Engine{}
+}
+
+// Add validates rule and appends it to the engine's rules.
+func (e *Engine) Add(rule Rule) error {
+	switch rule.Kind {
+	case Status:
+		if rule.Status == 0 {
+			rule.Status = 503
+		}
+		if rule.Status < 100 || rule.Status > 999 {
+			return fmt.Errorf("invalid fault status %d", rule.Status)
+		}
+	case Reset, Hang, Truncate, Malformed:
+	default:
+		return fmt.Errorf("unknown fault kind %q", rule.Kind)
+	}
+	if rule.Probability < 0 || rule.Probability > 1 {
+		return fmt.Errorf("fault probability %g is not between 0 and 1", rule.Probability)
+	}
+	rule.pattern = compilePattern(rule.URL)
+	methods := make([]string, len(rule.Methods))
+	for i, m := range rule.Methods {
+		methods[i] = strings.ToUpper(m)
+	}
+	rule.Methods = methods
+
+	e.mu.Lock()
+	e.rules = append(e.rules, &rule)
+	e.mu.Unlock()
+	return nil
+}
+
+// Match returns the rule whose fault the request should suffer, or nil to
+// let it through. Each call rolls the dice anew.
+func (e *Engine) Match(method, url string) *Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, rule := range e.rules {
+		if len(rule.Methods) > 0 && !slices.Contains(rule.Methods, method) {
+			continue
+		}
+		if !rule.pattern.MatchString(url) {
+			continue
+		}
+		if rand.Float64() >= rule.Probability {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// compilePattern turns a URL pattern into a regular expression in which
+// every * is a capture group. Patterns without a scheme match any scheme.
+func compilePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		pattern = "*"
+	}
+	scheme := ""
+	if !strings.Contains(pattern, "://") && pattern != "*" {
+		scheme = "[a-z][a-z0-9+.-]*://"
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, "(.*)")
+	return regexp.MustCompile("^" + scheme + expr + "$")
+}
+
+// TruncatedBody passes through the part of body a Truncate fault lets
+// through, given the declared content length (-1 if unknown), and then
+// fails with ErrTruncated.
+func (r *Rule) TruncatedBody(body io.Reader, contentLength int64) io.Reader {
+	n := r.TruncateAt
+	if n <= 0 {
+		n = max(contentLength/2, 0)
+	}
+	return io.MultiReader(io.LimitReader(body, n), errReader{ErrTruncated})
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,76 @@
+package tamper
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// LocalRule answers the requests matching URL from Path on disk instead of
+// from upstream.
+type LocalRule struct {
+	// URL is a pattern as for Rule.
+	URL string
+	// Path is a file, or a directory the text matched by the last * in URL
+	// is looked up in, e.g. "https://cdn.example.com/assets/*" mapped to
+	// "./dist" serves ./dist/app.js for /assets/app.js.
+	Path string
+	// Template runs the file through text/template with the request as
+	// data before serving it.
+	Template bool
+
+	pattern *regexp.Regexp
+}
+
+// LocalMap holds the Map Local rules. Rules are tried in the order they
+// were added and the first match decides.
+type LocalMap struct {
+	mu    sync.RWMutex
+	rules []*LocalRule
+}
+
+func NewLocalMap() *LocalMap {
+	return &LocalMap{}
+}
+
+// Add validates rule and appends it to the map.
+func (m *LocalMap) Add(rule LocalRule) error {
+	if rule.Path == "" {
+		return errors.New("map local rule has no path")
+	}
+	rule.pattern = compilePattern(rule.URL)
+
+	m.mu.Lock()
+	m.rules = append(m.rules, &rule)
+	m.mu.Unlock()
+	return nil
+}
+
+// Resolve returns the rule matching url, or nil, and the path on disk it
+// maps url to. The path need not exist, and is a directory when a rule
+// maps a directory without a * in its URL.
+func (m *LocalMap) Resolve(url string) (*LocalRule, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, rule := range m.rules {
+		match := rule.pattern.FindStringSubmatch(url)
+		if match == nil {
+			continue
+		}
+		if info, err := os.Stat(rule.Path); err != nil || !info.IsDir() || len(match) < 2 {
+			return rule, rule.Path
+		}
+		rest := match[len(match)-1]
+		if i := strings.IndexAny(rest, "?#"); i >= 0 {
+			rest = rest[:i]
+		}
+		// Cleaning against the root keeps ".." from escaping the directory.
+		rest = path.Clean("/" + rest)
+		return rule, filepath.Join(rule.Path, filepath.FromSlash(rest))
+	}
+	return nil, ""
+}
@@ -0,0 +1,67 @@
+package tamper
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// RemoteRule sends the requests whose URL matches From to the URL To
+// expands to instead.
+type RemoteRule struct {
+	// From is a regular expression matched against the full request URL,
+	// e.g. `^https://api\.example\.com/(.*)$`.
+	From string
+	// To is the replacement URL, in which $1 or ${name} refer to capture
+	// groups of From, e.g. "http://localhost:3000/$1".
+	To string
+	// PreserveHost keeps the client's Host header instead of the one of the
+	// rewritten URL.
+	PreserveHost bool
+
+	re *regexp.Regexp
+}
+
+// RemoteMap holds the Map Remote rules. Rules are tried in the order they
+// were added and the first match decides.
+type RemoteMap struct {
+	mu    sync.RWMutex
+	rules []*RemoteRule
+}
+
+func NewRemoteMap() *RemoteMap {
+	return &RemoteMap{}
+}
+
+// Add compiles rule and appends it to the map.
+func (m *RemoteMap) Add(rule RemoteRule) error {
+	re, err := regexp.Compile(rule.From)
+	if err != nil {
+		return err
+	}
+	if rule.To == "" {
+		return fmt.Errorf("map remote rule for %q has no target", rule.From)
+	}
+	rule.re = re
+
+	m.mu.Lock()
+	m.rules = append(m.rules, &rule)
+	m.mu.Unlock()
+	return nil
+}
+
+// Rewrite returns the rule matching url, or nil, and the URL it rewrites
+// url to. Only the matched part of url is replaced.
+func (m *RemoteMap) Rewrite(url string) (*RemoteRule, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, rule := range m.rules {
+		loc := rule.re.FindStringSubmatchIndex(url)
+		if loc == nil {
+			continue
+		}
+		expanded := rule.re.ExpandString(nil, rule.To, url, loc)
+		return rule, url[:loc[0]] + string(expanded) + url[loc[1]:]
+	}
+	return nil, ""
+}